@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "encoding/binary"
+
+// DefaultArenaChunkSize is the chunk size used by a zero Arena.
+const DefaultArenaChunkSize = 1 << 20
+
+// Arena stores the contents of many small strings in a few large
+// byte slices. Compared to holding each as a Go string, an Arena
+// saves the 16 byte string header per entry and, since its chunks
+// contain no pointers, the garbage collector never scans them.
+//
+// Add copies contents into the Arena and returns a handle, which Get
+// turns back into a view. A handle is the offset of its entry in the
+// Arena, so handles are always less than Stats().Allocated and fit in
+// a uint32 as long as less than 4GiB has been allocated.
+//
+// Each entry costs its length plus a varint length prefix, one byte
+// for entries under 128 bytes. Entries never span chunks; an entry
+// larger than the chunk size gets a chunk of its own.
+//
+// The zero value is an empty Arena using DefaultArenaChunkSize. An
+// Arena is not safe for concurrent use without synchronization; Get
+// may be called concurrently as long as nothing is added.
+type Arena struct {
+	chunkSize int
+	// chunks is indexed by handle/chunkSize. A chunk larger than
+	// chunkSize occupies several slots, each holding the rest of the
+	// chunk from that slot's offset on.
+	chunks [][]byte
+	cur    int // index of the chunk being filled
+	off    int // write offset in chunks[cur]
+	gen    uint64
+
+	st ArenaStats
+}
+
+// ArenaStats are statistics about an Arena's memory use.
+type ArenaStats struct {
+	Len       int   // number of entries added since the last Reset
+	Bytes     int64 // total length of those entries
+	Used      int64 // bytes of chunk space used, including length prefixes
+	Allocated int64 // bytes of chunk space allocated
+}
+
+// NewArena returns an empty Arena that allocates chunks of chunkSize
+// bytes. If chunkSize is zero or negative, DefaultArenaChunkSize is
+// used.
+func NewArena(chunkSize int) *Arena {
+	if chunkSize <= 0 {
+		chunkSize = DefaultArenaChunkSize
+	}
+	return &Arena{chunkSize: chunkSize}
+}
+
+func (a *Arena) size() int {
+	if a.chunkSize == 0 {
+		return DefaultArenaChunkSize
+	}
+	return a.chunkSize
+}
+
+// Add copies the contents of m into a and returns a handle for them.
+func (a *Arena) Add(m RO) uint64 {
+	m.check()
+	var prefix [binary.MaxVarintLen64]byte
+	pn := binary.PutUvarint(prefix[:], uint64(m.Len()))
+	need := pn + m.Len()
+	if len(a.chunks) == 0 || len(a.chunks[a.cur])-a.off < need {
+		a.grow(need)
+	}
+	c := a.chunks[a.cur]
+	h := uint64(a.cur)*uint64(a.size()) + uint64(a.off)
+	a.off += copy(c[a.off:], prefix[:pn])
+	a.off += copy(c[a.off:], m.m)
+	a.st.Len++
+	a.st.Bytes += int64(m.Len())
+	a.st.Used += int64(need)
+	return h
+}
+
+// grow starts a new chunk with room for at least need bytes.
+func (a *Arena) grow(need int) {
+	size := a.size()
+	slots := (need + size - 1) / size
+	a.cur = len(a.chunks)
+	a.off = 0
+	c := make([]byte, slots*size)
+	for i := 0; i < slots; i++ {
+		a.chunks = append(a.chunks, c[i*size:])
+	}
+	a.st.Allocated += int64(slots * size)
+}
+
+// Get returns a view of the contents added to a with handle h. The
+// view is valid until a is Reset. Get panics or returns garbage if h
+// was not returned by Add since the last Reset.
+func (a *Arena) Get(h uint64) RO {
+	size := uint64(a.size())
+	c := a.chunks[h/size][h%size:]
+	n, pn := binary.Uvarint(c)
+	return genView(c[pn:pn+int(n)], &a.gen)
+}
+
+// Len returns the number of entries added to a since the last Reset.
+func (a *Arena) Len() int { return a.st.Len }
+
+// Stats returns statistics about a's memory use.
+func (a *Arena) Stats() ArenaStats { return a.st }
+
+// Reset empties a, invalidating all handles and the views returned by
+// Get. The first chunk is kept for reuse and the rest are released to
+// the garbage collector.
+func (a *Arena) Reset() {
+	a.gen++
+	a.st = ArenaStats{}
+	if len(a.chunks) == 0 {
+		return
+	}
+	first := a.chunks[0]
+	if len(first) != a.size() {
+		first = nil // an oversized chunk; don't hold on to it
+	}
+	for i := range a.chunks {
+		a.chunks[i] = nil
+	}
+	a.chunks = a.chunks[:0]
+	a.cur, a.off = 0, 0
+	if first != nil {
+		a.chunks = append(a.chunks, first)
+		a.st.Allocated = int64(len(first))
+	}
+}
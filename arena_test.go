@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestArena(t *testing.T) {
+	for _, chunkSize := range []int{0, 1, 7, 64} {
+		a := NewArena(chunkSize)
+		var want []string
+		var hs []uint64
+		add := func(s string) {
+			want = append(want, s)
+			hs = append(hs, a.Add(S(s)))
+		}
+		for i := 0; i < 100; i++ {
+			add(fmt.Sprintf("path/to/file%d.go", i))
+		}
+		add("")
+		add(strings.Repeat("x", 200)) // longer than most chunks, two-byte length prefix
+		add("after")
+
+		if a.Len() != len(want) {
+			t.Errorf("chunkSize %d: Len = %d; want %d", chunkSize, a.Len(), len(want))
+		}
+		for i, h := range hs {
+			if got := a.Get(h).StringCopy(); got != want[i] {
+				t.Errorf("chunkSize %d: Get(%d) = %q; want %q", chunkSize, h, got, want[i])
+			}
+		}
+
+		st := a.Stats()
+		var bytes int64
+		for _, s := range want {
+			bytes += int64(len(s))
+		}
+		if st.Len != len(want) || st.Bytes != bytes {
+			t.Errorf("chunkSize %d: Stats = %+v; want Len %d, Bytes %d", chunkSize, st, len(want), bytes)
+		}
+		if st.Used < st.Bytes+int64(st.Len) || st.Allocated < st.Used {
+			t.Errorf("chunkSize %d: inconsistent Stats %+v", chunkSize, st)
+		}
+		for _, h := range hs {
+			if h >= uint64(st.Allocated) {
+				t.Errorf("chunkSize %d: handle %d >= Allocated %d", chunkSize, h, st.Allocated)
+			}
+		}
+
+		a.Reset()
+		if st := a.Stats(); st.Len != 0 || st.Bytes != 0 || st.Used != 0 {
+			t.Errorf("chunkSize %d: after Reset, Stats = %+v", chunkSize, st)
+		}
+		h := a.Add(S("new"))
+		if got := a.Get(h).StringCopy(); got != "new" {
+			t.Errorf("chunkSize %d: after Reset, Get = %q; want %q", chunkSize, got, "new")
+		}
+	}
+}
+
+func TestArenaZero(t *testing.T) {
+	var a Arena
+	h := a.Add(S("foo"))
+	if got := a.Get(h).StringCopy(); got != "foo" {
+		t.Errorf("Get = %q; want %q", got, "foo")
+	}
+	if got, want := a.Stats().Allocated, int64(DefaultArenaChunkSize); got != want {
+		t.Errorf("Allocated = %d; want %d", got, want)
+	}
+	a.Reset()
+	if got, want := a.Stats().Allocated, int64(DefaultArenaChunkSize); got != want {
+		t.Errorf("after Reset, Allocated = %d; want %d (first chunk kept)", got, want)
+	}
+}
+
+func TestArenaAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("views allocate in memdebug builds")
+	}
+	a := NewArena(1 << 16)
+	a.Add(S("warm up"))
+	m := S("some/file/path.go")
+	n := testing.AllocsPerRun(1000, func() {
+		if !a.Get(a.Add(m)).Equal(m) {
+			panic("wrong result")
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v; want 0", n)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "unsafe"
+
+// Buffer is an append-only byte buffer, like a bytes.Buffer that
+// can't be read from, that hands out read-only views of its contents.
+//
+// Views returned by View and ViewRange remain valid until the next
+// call to Reset or Truncate, which let the Buffer's memory be
+// overwritten. Each of those calls starts a new generation of the
+// Buffer; when built with the memdebug tag, using a view from an
+// earlier generation panics.
+//
+// The zero value is an empty Buffer ready to use. A Buffer must not
+// be copied after first use.
+type Buffer struct {
+	buf []byte
+	gen uint64
+}
+
+// Len returns the number of bytes in b.
+func (b *Buffer) Len() int { return len(b.buf) }
+
+// Cap returns the capacity of b's underlying storage.
+func (b *Buffer) Cap() int { return cap(b.buf) }
+
+// Grow grows b's capacity, if necessary, to guarantee space for
+// another n bytes without reallocating.
+func (b *Buffer) Grow(n int) {
+	if n < 0 {
+		panic("mem.Buffer.Grow: negative count")
+	}
+	if cap(b.buf)-len(b.buf) < n {
+		grown := make([]byte, len(b.buf), 2*cap(b.buf)+n)
+		copy(grown, b.buf)
+		b.buf = grown
+	}
+}
+
+// Write appends p to b. It implements io.Writer and always returns
+// len(p), nil.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// WriteString appends s to b. It implements io.StringWriter and
+// always returns len(s), nil.
+func (b *Buffer) WriteString(s string) (int, error) {
+	b.buf = append(b.buf, s...)
+	return len(s), nil
+}
+
+// WriteByte appends c to b. It implements io.ByteWriter and always
+// returns nil.
+func (b *Buffer) WriteByte(c byte) error {
+	b.buf = append(b.buf, c)
+	return nil
+}
+
+// AppendRO appends the contents of m to b.
+func (b *Buffer) AppendRO(m RO) {
+	b.buf = Append(b.buf, m)
+}
+
+// View returns a view of b's contents.
+func (b *Buffer) View() RO {
+	return genView(b.buf, &b.gen)
+}
+
+// ViewRange returns a view of b's contents from offset from up to,
+// but not including, offset to. It panics if the range is out of
+// bounds.
+func (b *Buffer) ViewRange(from, to int) RO {
+	return genView(b.buf[from:to], &b.gen)
+}
+
+// genView is like B, but in memdebug builds the returned view also
+// panics if used after *gen changes.
+func genView(p []byte, gen *uint64) RO {
+	if len(p) == 0 {
+		return RO{m: ""}
+	}
+	m := *(*unsafeString)(unsafe.Pointer(&stringHeader{&p[0], len(p)}))
+	return RO{d: newRODebug(m, gen), m: m}
+}
+
+// Truncate discards all but the first n bytes of b, starting a new
+// generation. It panics if n is negative or greater than b.Len().
+func (b *Buffer) Truncate(n int) {
+	if n < 0 || n > len(b.buf) {
+		panic("mem.Buffer: truncation out of range")
+	}
+	b.gen++
+	b.buf = b.buf[:n]
+}
+
+// Reset empties b, keeping its storage for reuse, and starts a new
+// generation.
+func (b *Buffer) Reset() { b.Truncate(0) }
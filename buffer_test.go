@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+var (
+	_ io.Writer       = (*Buffer)(nil)
+	_ io.ByteWriter   = (*Buffer)(nil)
+	_ io.StringWriter = (*Buffer)(nil)
+)
+
+func TestBuffer(t *testing.T) {
+	var b Buffer
+	if got := b.View(); got.Len() != 0 {
+		t.Fatalf("zero Buffer View = %q; want empty", got.StringCopy())
+	}
+	b.Write([]byte("foo"))
+	b.WriteString(" bar")
+	b.WriteByte(' ')
+	b.AppendRO(S("baz"))
+	if got, want := b.View().StringCopy(), "foo bar baz"; got != want {
+		t.Errorf("View = %q; want %q", got, want)
+	}
+	if got, want := b.Len(), 11; got != want {
+		t.Errorf("Len = %d; want %d", got, want)
+	}
+	if got, want := b.ViewRange(4, 7).StringCopy(), "bar"; got != want {
+		t.Errorf("ViewRange(4, 7) = %q; want %q", got, want)
+	}
+
+	// Views stay valid across later writes, even if they reallocate.
+	v := b.ViewRange(0, 3)
+	b.Write(bytes.Repeat([]byte("x"), 1000))
+	if got, want := v.StringCopy(), "foo"; got != want {
+		t.Errorf("view after growth = %q; want %q", got, want)
+	}
+
+	b.Truncate(3)
+	if got, want := b.View().StringCopy(), "foo"; got != want {
+		t.Errorf("after Truncate(3), View = %q; want %q", got, want)
+	}
+	b.Reset()
+	if b.Len() != 0 {
+		t.Errorf("after Reset, Len = %d; want 0", b.Len())
+	}
+	b.WriteString("again")
+	if got, want := b.View().StringCopy(), "again"; got != want {
+		t.Errorf("after Reset, View = %q; want %q", got, want)
+	}
+}
+
+func TestBufferGrow(t *testing.T) {
+	var b Buffer
+	b.WriteString("abc")
+	b.Grow(100)
+	if b.Cap()-b.Len() < 100 {
+		t.Fatalf("after Grow(100), Cap = %d, Len = %d", b.Cap(), b.Len())
+	}
+	if got := b.View().StringCopy(); got != "abc" {
+		t.Errorf("Grow lost contents: %q", got)
+	}
+	if memDebug {
+		return
+	}
+	n := testing.AllocsPerRun(100, func() {
+		b.Reset()
+		b.WriteString("hello, ")
+		b.AppendRO(S("world"))
+		if !b.View().EqualString("hello, world") {
+			panic("wrong result")
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v; want 0", n)
+	}
+}
+
+func TestBufferTruncatePanics(t *testing.T) {
+	for _, n := range []int{-1, 4} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Truncate(%d) didn't panic", n)
+				}
+			}()
+			var b Buffer
+			b.WriteString("abc")
+			b.Truncate(n)
+		}()
+	}
+}
@@ -0,0 +1,30 @@
+//go:build go1.15
+// +build go1.15
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "strconv"
+
+// ParseComplex returns a complex number from m, using
+// strconv.ParseComplex.
+func ParseComplex(m RO, bitSize int) (complex128, error) {
+	m.check()
+	v, err := strconv.ParseComplex(m.str(), bitSize)
+	return v, ownNumError(err, m)
+}
@@ -0,0 +1,40 @@
+//go:build go1.15
+// +build go1.15
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseComplex(t *testing.T) {
+	for _, s := range []string{"", "1", "1+2i", "(3-4.5i)", "-i", "1e400+1i", "NaN+Infi", "1+2j", "x"} {
+		for _, bitSize := range []int{64, 128} {
+			want, wantErr := strconv.ParseComplex(s, bitSize)
+			got, err := ParseComplex(S(s), bitSize)
+			if (err == nil) != (wantErr == nil) || wantErr != nil && err.Error() != wantErr.Error() {
+				t.Errorf("ParseComplex(%q, %d) error = %v; want %v", s, bitSize, err, wantErr)
+			}
+			if got != want && !(got != got && want != want) {
+				t.Errorf("ParseComplex(%q, %d) = %v; want %v", s, bitSize, got, want)
+			}
+		}
+	}
+}
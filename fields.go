@@ -48,30 +48,36 @@ func AppendFields(dst []RO, m RO) []RO {
 		return AppendFieldsFunc(dst, m, unicode.IsSpace)
 	}
 	// ASCII fast path
-	fieldStart := 0
-	i := 0
-	// Skip spaces in the front of the input.
+	for i := 0; ; {
+		start, end, _ := nextASCIIField(s, i) // s is all ASCII
+		if start == end {
+			break
+		}
+		dst = append(dst, RO{d: m.d, m: s[start:end]})
+		i = end
+	}
+	return dst
+}
+
+// nextASCIIField returns the bounds of the first space-separated
+// field in s[i:]. If there is none, start and end are both len(s). If
+// it meets a non-ASCII byte first, it stops there and reports
+// ascii == false, with start at the beginning of the field the byte
+// may belong to.
+func nextASCIIField(s unsafeString, i int) (start, end int, ascii bool) {
+	// Skip spaces before the field.
 	for i < len(s) && asciiSpace[s[i]] != 0 {
 		i++
 	}
-	fieldStart = i
-	for i < len(s) {
-		if asciiSpace[s[i]] == 0 {
-			i++
-			continue
+	start = i
+	for ; i < len(s); i++ {
+		if c := s[i]; c >= utf8.RuneSelf {
+			return start, i, false
+		} else if asciiSpace[c] != 0 {
+			break
 		}
-		dst = append(dst, RO{m: s[fieldStart:i]})
-		i++
-		// Skip spaces in between fields.
-		for i < len(s) && asciiSpace[s[i]] != 0 {
-			i++
-		}
-		fieldStart = i
 	}
-	if fieldStart < len(s) { // Last field might end at EOF.
-		dst = append(dst, RO{m: s[fieldStart:]})
-	}
-	return dst
+	return start, i, true
 }
 
 // AppendFieldsFunc is like strings.FieldsFunc, but is append-like and uses a mem.RO instead of a string.
@@ -84,7 +90,7 @@ func AppendFieldsFunc(dst []RO, m RO, f func(rune) bool) []RO {
 	for i, rune := range s {
 		if f(rune) {
 			if wasField {
-				dst = append(dst, RO{m: unsafeString(s[fromIndex:i])})
+				dst = append(dst, RO{d: m.d, m: unsafeString(s[fromIndex:i])})
 				wasField = false
 			}
 		} else {
@@ -97,7 +103,7 @@ func AppendFieldsFunc(dst []RO, m RO, f func(rune) bool) []RO {
 
 	// Last field might end at EOF.
 	if wasField {
-		dst = append(dst, RO{m: unsafeString(s[fromIndex:len(s)])})
+		dst = append(dst, RO{d: m.d, m: unsafeString(s[fromIndex:len(s)])})
 	}
 	return dst
 }
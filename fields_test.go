@@ -60,6 +60,15 @@ func eq(a []RO, b []string) bool {
 	return true
 }
 
+// strs returns copies of a's contents, for error messages.
+func strs(a []RO) []string {
+	out := make([]string, len(a))
+	for i, m := range a {
+		out[i] = m.StringCopy()
+	}
+	return out
+}
+
 func TestFields(t *testing.T) {
 	for _, tt := range fieldstests {
 		a := AppendFields(nil, S(tt.s))
@@ -17,6 +17,7 @@ limitations under the License.
 package mem // import "go4.org/mem"
 
 import (
+	"hash/maphash"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -54,60 +55,100 @@ func equalFoldRune(sr, tr rune) bool {
 	return false
 }
 
+// decodeFoldRune is like utf8.DecodeRuneInString, but returns a
+// negative rune, distinct for each byte value, for an invalid UTF-8
+// byte. Those fold only to themselves, so under equalFoldRune an
+// invalid byte matches the same byte and nothing else.
+func decodeFoldRune(s string) (rune, int) {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError && size == 1 {
+		return -1 - rune(s[0]), 1
+	}
+	return r, size
+}
+
+// decodeLastFoldRune is like decodeFoldRune, but decodes the last
+// rune of s.
+func decodeLastFoldRune(s string) (rune, int) {
+	r, size := utf8.DecodeLastRuneInString(s)
+	if r == utf8.RuneError && size == 1 {
+		return -1 - rune(s[len(s)-1]), 1
+	}
+	return r, size
+}
+
 // HasPrefixFold is like HasPrefix but uses Unicode case-folding,
 // matching case insensitively.
+//
+// Unlike EqualFold, which follows strings.EqualFold in treating every
+// invalid UTF-8 byte as U+FFFD, HasPrefixFold and the other prefix,
+// suffix and searching Fold functions match an invalid UTF-8 byte only
+// to the same byte. Text that matches byte for byte always matches,
+// even where it doesn't start on a rune boundary, as with Index.
 func HasPrefixFold(s, prefix RO) bool {
+	_, ok := prefixFoldLen(s, prefix)
+	return ok
+}
+
+// prefixFoldLen reports whether s starts with prefix under Unicode
+// case-folding and, if so, the length in bytes of the matching
+// prefix of s, which may differ from prefix.Len().
+func prefixFoldLen(s, prefix RO) (n int, ok bool) {
 	if strings.HasPrefix(s.str(), prefix.str()) {
 		// Exact case fast path.
-		return true
+		return prefix.Len(), true
 	}
-	for _, pr := range prefix.str() {
-		if s.Len() == 0 {
-			return false
+	for p := prefix.str(); p != ""; {
+		if n == s.Len() {
+			return 0, false
 		}
+		pr, psize := decodeFoldRune(p)
+		p = p[psize:]
 		// step with s, too
-		sr, size := utf8.DecodeRuneInString(s.str())
-		if sr == utf8.RuneError {
-			return false
-		}
-		s = s.SliceFrom(size)
+		sr, size := decodeFoldRune(s.str()[n:])
+		n += size
 		if !equalFoldRune(sr, pr) {
-			return false
+			return 0, false
 		}
 	}
-	return true
+	return n, true
 }
 
 // HasSuffixFold is like HasSuffix but uses Unicode case-folding,
 // matching case insensitively.
 func HasSuffixFold(s, suffix RO) bool {
+	_, ok := suffixFoldStart(s, suffix)
+	return ok
+}
+
+// suffixFoldStart reports whether s ends with suffix under Unicode
+// case-folding and, if so, the byte offset in s where the matching
+// suffix starts.
+func suffixFoldStart(s, suffix RO) (i int, ok bool) {
 	if suffix.Len() == 0 {
-		return true
+		return s.Len(), true
 	}
 	if strings.HasSuffix(s.str(), suffix.str()) {
 		// Exact case fast path.
-		return true
+		return s.Len() - suffix.Len(), true
 	}
 	// count the runes and bytes in s, but only until rune count of suffix
 	bo, so := s.Len(), suffix.Len()
 	for bo > 0 && so > 0 {
-		r, size := utf8.DecodeLastRuneInString(s.str()[:bo])
-		if r == utf8.RuneError {
-			return false
-		}
+		r, size := decodeLastFoldRune(s.str()[:bo])
 		bo -= size
 
-		sr, size := utf8.DecodeLastRuneInString(suffix.str()[:so])
-		if sr == utf8.RuneError {
-			return false
-		}
+		sr, size := decodeLastFoldRune(suffix.str()[:so])
 		so -= size
 
 		if !equalFoldRune(r, sr) {
-			return false
+			return 0, false
 		}
 	}
-	return so == 0
+	if so != 0 {
+		return 0, false
+	}
+	return bo, true
 }
 
 // ContainsFold is like Contains but uses Unicode case-folding for a case insensitive substring search.
@@ -130,3 +171,222 @@ func ContainsFold(s, substr RO) bool {
 	}
 	return false
 }
+
+// firstRune returns the first rune of m, which must be non-empty.
+func firstRune(m RO) rune {
+	r := rune(m.At(0))
+	if r >= utf8.RuneSelf {
+		r, _ = utf8.DecodeRuneInString(m.str())
+	}
+	return r
+}
+
+// indexFold returns the byte offset i in s of the first match of
+// substr under Unicode case-folding and the length n in bytes of that
+// match, or -1, 0 if there's none.
+func indexFold(s, substr RO) (i, n int) {
+	if substr.Len() == 0 {
+		return 0, 0
+	}
+	// An exact match bounds where the first folded match can start.
+	end := strings.Index(s.str(), substr.str())
+	if end < 0 {
+		end = s.Len()
+	}
+	fr := firstRune(substr)
+	for i, r := range s.str()[:end] {
+		if !equalFoldRune(r, fr) {
+			continue
+		}
+		if n, ok := prefixFoldLen(s.SliceFrom(i), substr); ok {
+			return i, n
+		}
+	}
+	if end < s.Len() {
+		return end, substr.Len()
+	}
+	return -1, 0
+}
+
+// IndexFold is like Index but uses Unicode case-folding, matching
+// case insensitively. It returns the byte offset in s of the first
+// match, or -1 if there's none.
+//
+// The match in s may have a different length than substr, as some
+// case-folded runes have different UTF-8 lengths. Use CutFold to get
+// the text following the match.
+func IndexFold(s, substr RO) int {
+	i, _ := indexFold(s, substr)
+	return i
+}
+
+// LastIndexFold is like LastIndex but uses Unicode case-folding,
+// matching case insensitively. It returns the byte offset in s of the
+// last match, or -1 if there's none.
+func LastIndexFold(s, substr RO) int {
+	if substr.Len() == 0 {
+		return s.Len()
+	}
+	// An exact match bounds where the last folded match can start.
+	start := strings.LastIndex(s.str(), substr.str())
+	fr := firstRune(substr)
+	for i := s.Len(); i > start+1; {
+		r, size := utf8.DecodeLastRuneInString(s.str()[:i])
+		i -= size
+		if i <= start {
+			break
+		}
+		if !equalFoldRune(r, fr) {
+			continue
+		}
+		if _, ok := prefixFoldLen(s.SliceFrom(i), substr); ok {
+			return i
+		}
+	}
+	return start
+}
+
+// CountFold is like strings.Count but uses Unicode case-folding. It
+// counts the non-overlapping case-insensitive matches of substr in s.
+// If substr is empty, CountFold returns 1 + the number of runes in s.
+func CountFold(s, substr RO) int {
+	if substr.Len() == 0 {
+		return RuneCount(s) + 1
+	}
+	c := 0
+	for {
+		i, n := indexFold(s, substr)
+		if i < 0 {
+			return c
+		}
+		c++
+		s = s.SliceFrom(i + n)
+	}
+}
+
+// CutFold is like Cut but uses Unicode case-folding to find sep.
+// before and after are views of s.
+func CutFold(s, sep RO) (before, after RO, found bool) {
+	if i, n := indexFold(s, sep); i >= 0 {
+		return s.SliceTo(i), s.SliceFrom(i + n), true
+	}
+	return s, S(""), false
+}
+
+// CutPrefixFold is like CutPrefix but uses Unicode case-folding to
+// match prefix.
+func CutPrefixFold(s, prefix RO) (after RO, found bool) {
+	n, ok := prefixFoldLen(s, prefix)
+	if !ok {
+		return s, false
+	}
+	return s.SliceFrom(n), true
+}
+
+// CutSuffixFold is like CutSuffix but uses Unicode case-folding to
+// match suffix.
+func CutSuffixFold(s, suffix RO) (before RO, found bool) {
+	i, ok := suffixFoldStart(s, suffix)
+	if !ok {
+		return s, false
+	}
+	return s.SliceTo(i), true
+}
+
+// TrimPrefixFold is like TrimPrefix but uses Unicode case-folding to
+// match prefix.
+func TrimPrefixFold(s, prefix RO) RO {
+	after, _ := CutPrefixFold(s, prefix)
+	return after
+}
+
+// TrimSuffixFold is like TrimSuffix but uses Unicode case-folding to
+// match suffix.
+func TrimSuffixFold(s, suffix RO) RO {
+	before, _ := CutSuffixFold(s, suffix)
+	return before
+}
+
+// CompareFold is like Compare, but uses Unicode case-folding. It
+// returns 0 exactly when EqualFold reports true. Otherwise it orders
+// a and b as if each rune were replaced by the smallest rune it folds
+// to (so ASCII letters compare as upper case).
+func CompareFold(a, b RO) int {
+	s, t := a.str(), b.str()
+	for s != "" && t != "" {
+		var sr, tr rune
+		if c := s[0]; c < utf8.RuneSelf {
+			sr, s = rune(c), s[1:]
+		} else {
+			r, size := utf8.DecodeRuneInString(s)
+			sr, s = r, s[size:]
+		}
+		if c := t[0]; c < utf8.RuneSelf {
+			tr, t = rune(c), t[1:]
+		} else {
+			r, size := utf8.DecodeRuneInString(t)
+			tr, t = r, t[size:]
+		}
+		if sr == tr {
+			continue
+		}
+		sr, tr = foldRune(sr), foldRune(tr)
+		if sr < tr {
+			return -1
+		}
+		if sr > tr {
+			return +1
+		}
+	}
+	switch {
+	case t != "":
+		return -1
+	case s != "":
+		return +1
+	}
+	return 0
+}
+
+// foldRune returns the canonical member of r's case-folding orbit:
+// the smallest rune that equalFoldRune considers equal to r.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r
+	}
+	canon := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < canon {
+			canon = f
+		}
+	}
+	return canon
+}
+
+// MapHashFold is like MapHash, but returns the same hash for any two
+// values for which EqualFold reports true, using Unicode simple
+// case-folding.
+func (r RO) MapHashFold() uint64 {
+	var hash maphash.Hash
+	hash.SetSeed(seed)
+	var buf [64]byte
+	n := 0
+	s := r.str()
+	for i := 0; i < len(s); {
+		if n > len(buf)-utf8.UTFMax {
+			hash.Write(buf[:n])
+			n = 0
+		}
+		c := rune(s[i])
+		size := 1
+		if c >= utf8.RuneSelf {
+			c, size = utf8.DecodeRuneInString(s[i:])
+		}
+		i += size
+		n += utf8.EncodeRune(buf[n:], foldRune(c))
+	}
+	hash.Write(buf[:n])
+	return hash.Sum64()
+}
@@ -75,3 +75,146 @@ func runFoldTests(t *testing.T, fn func(RO, RO) bool, funcName string, tests []f
 		}
 	}
 }
+
+func TestIndexFold(t *testing.T) {
+	tests := []struct {
+		s, substr   string
+		index, last int
+		count       int
+	}{
+		{"", "", 0, 0, 1},
+		{"abc", "", 0, 3, 4},
+		{"", "a", -1, -1, 0},
+		{"foo", "FOO", 0, 0, 1},
+		{"FOO foo", "foo", 0, 4, 2},
+		{"xFoOx fOo", "foo", 1, 6, 2},
+		{"bar", "foo", -1, -1, 0},
+		{"aaaa", "AA", 0, 2, 2},
+		{"xKy k", "k", 1, 6, 2}, // KELVIN SIGN is 3 bytes, k is 1
+		{"xky K", "K", 1, 4, 2},
+		{"ſtraSSe STRASSE", "strasse", 0, 9, 2},
+		// Invalid UTF-8 matches only itself, but byte-for-byte
+		// matches count even inside a rune.
+		{"\xe9\x80\xa8", "\x80", 1, 1, 1},
+		{"A\xffa\xff", "a\xff", 0, 2, 2},
+		{"\xfe", "\xff", -1, -1, 0},
+		{"\xff", "\ufffd", -1, -1, 0},
+		{"\ufffd", "\xff", -1, -1, 0},
+	}
+	for _, tt := range tests {
+		s, substr := S(tt.s), S(tt.substr)
+		if got := IndexFold(s, substr); got != tt.index {
+			t.Errorf("IndexFold(%q, %q) = %d; want %d", tt.s, tt.substr, got, tt.index)
+		}
+		if got := LastIndexFold(s, substr); got != tt.last {
+			t.Errorf("LastIndexFold(%q, %q) = %d; want %d", tt.s, tt.substr, got, tt.last)
+		}
+		if got := CountFold(s, substr); got != tt.count {
+			t.Errorf("CountFold(%q, %q) = %d; want %d", tt.s, tt.substr, got, tt.count)
+		}
+		if got, want := tt.index >= 0, ContainsFold(s, substr); got != want {
+			t.Errorf("IndexFold(%q, %q) disagrees with ContainsFold", tt.s, tt.substr)
+		}
+	}
+}
+
+func TestCutFold(t *testing.T) {
+	tests := []struct {
+		s, sep        string
+		before, after string
+		found         bool
+	}{
+		{"Content-Type: text/plain", "content-type:", "", " text/plain", true},
+		{"aKb", "K", "a", "b", true},
+		{"aKb", "K", "a", "b", true},
+		{"abc", "X", "abc", "", false},
+		{"abc", "", "", "abc", true},
+	}
+	for _, tt := range tests {
+		before, after, found := CutFold(S(tt.s), S(tt.sep))
+		if !before.EqualString(tt.before) || !after.EqualString(tt.after) || found != tt.found {
+			t.Errorf("CutFold(%q, %q) = %q, %q, %v; want %q, %q, %v", tt.s, tt.sep, before.StringCopy(), after.StringCopy(), found, tt.before, tt.after, tt.found)
+		}
+	}
+}
+
+func TestCutPrefixSuffixFold(t *testing.T) {
+	tests := []struct {
+		s, affix     string
+		afterPrefix  string
+		prefixFound  bool
+		beforeSuffix string
+		suffixFound  bool
+	}{
+		{"FooBar", "foo", "Bar", true, "FooBar", false},
+		{"FooBar", "BAR", "FooBar", false, "Foo", true},
+		{"KbK", "k", "bK", true, "Kb", true},
+		{"kbk", "K", "bk", true, "kb", true},
+		{"abc", "", "abc", true, "abc", true},
+		{"ab", "abc", "ab", false, "ab", false},
+	}
+	for _, tt := range tests {
+		s, affix := S(tt.s), S(tt.affix)
+		if after, found := CutPrefixFold(s, affix); !after.EqualString(tt.afterPrefix) || found != tt.prefixFound {
+			t.Errorf("CutPrefixFold(%q, %q) = %q, %v; want %q, %v", tt.s, tt.affix, after.StringCopy(), found, tt.afterPrefix, tt.prefixFound)
+		}
+		if got := TrimPrefixFold(s, affix); !got.EqualString(tt.afterPrefix) {
+			t.Errorf("TrimPrefixFold(%q, %q) = %q; want %q", tt.s, tt.affix, got.StringCopy(), tt.afterPrefix)
+		}
+		if before, found := CutSuffixFold(s, affix); !before.EqualString(tt.beforeSuffix) || found != tt.suffixFound {
+			t.Errorf("CutSuffixFold(%q, %q) = %q, %v; want %q, %v", tt.s, tt.affix, before.StringCopy(), found, tt.beforeSuffix, tt.suffixFound)
+		}
+		if got := TrimSuffixFold(s, affix); !got.EqualString(tt.beforeSuffix) {
+			t.Errorf("TrimSuffixFold(%q, %q) = %q; want %q", tt.s, tt.affix, got.StringCopy(), tt.beforeSuffix)
+		}
+	}
+}
+
+func TestMapHashFold(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"", ""},
+		{"abc", "ABC"},
+		{"k", "K"},
+		{"σς", "ΣΣ"},
+		{"\xff", "�"},
+		{"a long key longer than the sixty four byte hashing buffer ....", "A LONG KEY LONGER THAN THE SIXTY FOUR BYTE HASHING BUFFER ...."},
+	}
+	for _, tt := range tests {
+		if !EqualFold(S(tt.a), S(tt.b)) {
+			t.Fatalf("bad test: %q and %q aren't EqualFold", tt.a, tt.b)
+		}
+		if S(tt.a).MapHashFold() != S(tt.b).MapHashFold() {
+			t.Errorf("MapHashFold(%q) != MapHashFold(%q)", tt.a, tt.b)
+		}
+	}
+}
+
+func TestCompareFold(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "a", -1},
+		{"abc", "ABC", 0},
+		{"abc", "ABD", -1},
+		{"abd", "ABC", +1},
+		{"ab", "ABC", -1},
+		{"k", "K", 0},
+		{"straße", "STRAẞE", 0},
+		{"a", "_", -1}, // letters compare as upper case
+		{"\xff", "�", 0},
+	}
+	for _, tt := range tests {
+		a, b := S(tt.a), S(tt.b)
+		if got := CompareFold(a, b); got != tt.want {
+			t.Errorf("CompareFold(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareFold(b, a); got != -tt.want {
+			t.Errorf("CompareFold(%q, %q) = %d; want %d", tt.b, tt.a, got, -tt.want)
+		}
+		if got := CompareFold(a, b) == 0; got != EqualFold(a, b) {
+			t.Errorf("CompareFold(%q, %q) == 0 is %v, but EqualFold is %v", tt.a, tt.b, got, !got)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "hash/maphash"
+
+// The functions in this file are ASCII-only versions of those in
+// fold.go. They treat only 'A'-'Z' and 'a'-'z' as having case and
+// never decode runes, so they're suited to protocol tokens (HTTP
+// header names, DNS labels, SMTP verbs) that are ASCII by spec. On
+// ASCII input they agree with their Unicode counterparts. Non-ASCII
+// bytes only match themselves.
+
+// asciiLower maps each byte to its ASCII lower case.
+var asciiLower = func() (t [256]byte) {
+	for i := range t {
+		c := byte(i)
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+// asciiUpper maps each byte to its ASCII upper case.
+var asciiUpper = func() (t [256]byte) {
+	for i := range t {
+		c := byte(i)
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+const (
+	swarOnes  = 0x0101010101010101
+	swarHighs = 0x8080808080808080
+)
+
+// load64 returns the 8 bytes of s starting at i as a little-endian
+// uint64.
+func load64(s unsafeString, i int) uint64 {
+	s = s[i : i+8]
+	return uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 |
+		uint64(s[4])<<32 | uint64(s[5])<<40 | uint64(s[6])<<48 | uint64(s[7])<<56
+}
+
+// lower64 lowercases the ASCII letters in the 8 bytes packed in x.
+func lower64(x uint64) uint64 {
+	low7 := x &^ swarHighs
+	ge := low7 + (0x80-'A')*swarOnes   // high bit set in bytes >= 'A'
+	gt := low7 + (0x80-'Z'-1)*swarOnes // high bit set in bytes > 'Z'
+	upper := (ge &^ gt) &^ x & swarHighs
+	return x | upper>>2 // set 0x20 in the upper case letters
+}
+
+// equalFoldASCII reports whether s and t, which must be the same
+// length, are equal under ASCII case-folding.
+func equalFoldASCII(s, t unsafeString) bool {
+	i := 0
+	for ; i+8 <= len(s); i += 8 {
+		if x, y := load64(s, i), load64(t, i); x != y && lower64(x) != lower64(y) {
+			return false
+		}
+	}
+	for ; i < len(s); i++ {
+		if asciiLower[s[i]] != asciiLower[t[i]] {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualFoldASCII is like EqualFold, but only folds ASCII letters.
+func EqualFoldASCII(m, m2 RO) bool {
+	return m.Len() == m2.Len() && equalFoldASCII(m.m, m2.m)
+}
+
+// HasPrefixFoldASCII is like HasPrefixFold, but only folds ASCII
+// letters.
+func HasPrefixFoldASCII(s, prefix RO) bool {
+	return s.Len() >= prefix.Len() && equalFoldASCII(s.m[:prefix.Len()], prefix.m)
+}
+
+// HasSuffixFoldASCII is like HasSuffixFold, but only folds ASCII
+// letters.
+func HasSuffixFoldASCII(s, suffix RO) bool {
+	return s.Len() >= suffix.Len() && equalFoldASCII(s.m[s.Len()-suffix.Len():], suffix.m)
+}
+
+// IndexFoldASCII is like IndexFold, but only folds ASCII letters.
+func IndexFoldASCII(s, substr RO) int {
+	n := substr.Len()
+	if n == 0 {
+		return 0
+	}
+	c0 := asciiLower[substr.m[0]]
+	for i := 0; i+n <= s.Len(); i++ {
+		if asciiLower[s.m[i]] == c0 && equalFoldASCII(s.m[i:i+n], substr.m) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ContainsFoldASCII is like ContainsFold, but only folds ASCII
+// letters.
+func ContainsFoldASCII(s, substr RO) bool {
+	return IndexFoldASCII(s, substr) >= 0
+}
+
+// MapHashFoldASCII is like MapHash, but returns the same hash for any
+// two values for which EqualFoldASCII reports true.
+func (r RO) MapHashFoldASCII() uint64 {
+	var hash maphash.Hash
+	hash.SetSeed(seed)
+	var buf [64]byte
+	for s := r.m; len(s) > 0; {
+		n := copy(buf[:], s)
+		for i, c := range buf[:n] {
+			buf[i] = asciiLower[c]
+		}
+		hash.Write(buf[:n])
+		s = s[n:]
+	}
+	return hash.Sum64()
+}
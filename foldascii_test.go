@@ -0,0 +1,146 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLower64(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		c := byte(i)
+		x := uint64(c) * swarOnes
+		if got, want := lower64(x), uint64(asciiLower[c])*swarOnes; got != want {
+			t.Errorf("lower64(%#x) = %#x; want %#x", x, got, want)
+		}
+	}
+}
+
+func TestFoldASCII(t *testing.T) {
+	runFoldTests(t, EqualFoldASCII, "EqualFoldASCII", []foldTest{
+		{"", "", true},
+		{"Content-Length", "content-length", true},
+		{"CONTENT-LENGTH-AND-MORE", "content-length-and-more", true},
+		{"content-length", "content-lengtH", true},
+		{"content-length", "content-lengt", false},
+		{"content-length", "content_length", false},
+		{"@[`{", "`{@[", false}, // just outside the letter ranges
+		{"\u212a", "k", false},  // KELVIN SIGN isn't ASCII
+		{"é", "É", false},
+		{"é", "é", true},
+	})
+	runFoldTests(t, HasPrefixFoldASCII, "HasPrefixFoldASCII", []foldTest{
+		{"foo", "", true},
+		{"FOO", "foo", true},
+		{"foo", "food", false},
+	})
+	runFoldTests(t, HasSuffixFoldASCII, "HasSuffixFoldASCII", []foldTest{
+		{"foo", "", true},
+		{" foo", "FoO", true},
+		{"oo", "foo", false},
+	})
+	runFoldTests(t, ContainsFoldASCII, "ContainsFoldASCII", []foldTest{
+		{"", "", true},
+		{"", "foo", false},
+		{" FOO ", "foo", true},
+		{" FOO ", "bar", false},
+		{"EHLO example.com", "example.COM", true},
+	})
+}
+
+func FuzzFoldASCII(f *testing.F) {
+	f.Add("Content-Type", "content-type")
+	f.Add("Transfer-Encoding: chunked", "ENCODING")
+	f.Add("MAIL FROM:<a@b>", "mail from")
+	f.Add("@[`{", "`{@[")
+	f.Fuzz(func(t *testing.T, a, b string) {
+		if !isASCII(S(a)) || !isASCII(S(b)) {
+			t.Skip()
+		}
+		ma, mb := S(a), S(b)
+		if got, want := EqualFoldASCII(ma, mb), strings.EqualFold(a, b); got != want {
+			t.Errorf("EqualFoldASCII(%q, %q) = %v; want %v", a, b, got, want)
+		}
+		if got, want := HasPrefixFoldASCII(ma, mb), HasPrefixFold(ma, mb); got != want {
+			t.Errorf("HasPrefixFoldASCII(%q, %q) = %v; want %v", a, b, got, want)
+		}
+		if got, want := HasSuffixFoldASCII(ma, mb), HasSuffixFold(ma, mb); got != want {
+			t.Errorf("HasSuffixFoldASCII(%q, %q) = %v; want %v", a, b, got, want)
+		}
+		if got, want := IndexFoldASCII(ma, mb), IndexFold(ma, mb); got != want {
+			t.Errorf("IndexFoldASCII(%q, %q) = %v; want %v", a, b, got, want)
+		}
+		if got, want := ContainsFoldASCII(ma, mb), ContainsFold(ma, mb); got != want {
+			t.Errorf("ContainsFoldASCII(%q, %q) = %v; want %v", a, b, got, want)
+		}
+	})
+}
+
+func BenchmarkEqualFold(b *testing.B) {
+	x, y := S("Access-Control-Allow-Credentials"), S("access-control-allow-credentials")
+	b.Run("Unicode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !EqualFold(x, y) {
+				b.Fatal("not equal")
+			}
+		}
+	})
+	b.Run("ASCII", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !EqualFoldASCII(x, y) {
+				b.Fatal("not equal")
+			}
+		}
+	})
+}
+
+func TestMapHashFoldASCII(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"", ""},
+		{"Content-Type", "content-type"},
+		{"a long key longer than the sixty four byte hashing buffer ....", "A LONG KEY LONGER THAN THE SIXTY FOUR BYTE HASHING BUFFER ...."},
+	}
+	for _, tt := range tests {
+		if S(tt.a).MapHashFoldASCII() != S(tt.b).MapHashFoldASCII() {
+			t.Errorf("MapHashFoldASCII(%q) != MapHashFoldASCII(%q)", tt.a, tt.b)
+		}
+	}
+	if S("k").MapHashFoldASCII() == S("K").MapHashFoldASCII() {
+		t.Errorf("MapHashFoldASCII folded KELVIN SIGN")
+	}
+}
+
+func FuzzMapHashFold(f *testing.F) {
+	f.Add("Content-Type")
+	f.Add("straße ſ K")
+	f.Add("\xff\xfe")
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, s2 := range []string{strings.ToUpper(s), strings.ToLower(s), strings.ToTitle(s)} {
+			a, b := S(s), S(s2)
+			if EqualFold(a, b) && a.MapHashFold() != b.MapHashFold() {
+				t.Errorf("MapHashFold(%q) != MapHashFold(%q)", s, s2)
+			}
+			if EqualFoldASCII(a, b) && a.MapHashFoldASCII() != b.MapHashFoldASCII() {
+				t.Errorf("MapHashFoldASCII(%q) != MapHashFoldASCII(%q)", s, s2)
+			}
+		}
+	})
+}
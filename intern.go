@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const internShards = 64
+
+// Interner deduplicates strings. It returns a canonical Go string for
+// the contents of an RO, allocating a copy only the first time those
+// contents are seen.
+//
+// The zero value is an empty, unbounded Interner ready to use; use
+// NewInterner to bound its size. An Interner is safe for concurrent
+// use.
+type Interner struct {
+	perShard int // max entries per shard, or 0 for unbounded
+
+	once   sync.Once // initializes shards
+	shards [internShards]*internShard
+}
+
+type internShard struct {
+	// Accessed atomically; first in the struct for 64-bit alignment.
+	hits, misses, evictions uint64
+
+	mu sync.RWMutex
+	m  map[string]string
+}
+
+// InternerStats are statistics about an Interner's use.
+type InternerStats struct {
+	Len       int    // number of strings currently held
+	Hits      uint64 // Intern calls that returned an existing string
+	Misses    uint64 // Intern calls that allocated a new string
+	Evictions uint64 // strings dropped to stay within the size bound
+}
+
+// NewInterner returns a new Interner holding at most approximately
+// maxSize strings. If maxSize is zero or negative, the Interner is
+// unbounded.
+//
+// When a bounded Interner is full, interning new contents evicts an
+// arbitrary existing string. Strings already returned to callers stay
+// valid; a later Intern of evicted contents just returns a new copy.
+func NewInterner(maxSize int) *Interner {
+	in := new(Interner)
+	if maxSize > 0 {
+		in.perShard = (maxSize + internShards - 1) / internShards
+	}
+	return in
+}
+
+func (in *Interner) init() {
+	for i := range in.shards {
+		in.shards[i] = &internShard{m: make(map[string]string)}
+	}
+}
+
+// Intern returns a string with the same contents as m. If the
+// Interner already holds such a string, it's returned without
+// allocating.
+func (in *Interner) Intern(m RO) string {
+	in.once.Do(in.init)
+	sh := in.shards[m.MapHash()%internShards]
+
+	sh.mu.RLock()
+	s, ok := sh.m[m.str()]
+	sh.mu.RUnlock()
+	if ok {
+		atomic.AddUint64(&sh.hits, 1)
+		return s
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if s, ok := sh.m[m.str()]; ok {
+		// Lost a race with another Intern of the same contents.
+		atomic.AddUint64(&sh.hits, 1)
+		return s
+	}
+	if in.perShard > 0 && len(sh.m) >= in.perShard {
+		for k := range sh.m {
+			delete(sh.m, k)
+			atomic.AddUint64(&sh.evictions, 1)
+			break
+		}
+	}
+	s = m.StringCopy()
+	sh.m[s] = s
+	atomic.AddUint64(&sh.misses, 1)
+	return s
+}
+
+// Len returns the number of strings held by the Interner.
+func (in *Interner) Len() int {
+	in.once.Do(in.init)
+	n := 0
+	for _, sh := range in.shards {
+		sh.mu.RLock()
+		n += len(sh.m)
+		sh.mu.RUnlock()
+	}
+	return n
+}
+
+// Stats returns statistics about the Interner's use so far.
+func (in *Interner) Stats() InternerStats {
+	in.once.Do(in.init)
+	var st InternerStats
+	for _, sh := range in.shards {
+		st.Hits += atomic.LoadUint64(&sh.hits)
+		st.Misses += atomic.LoadUint64(&sh.misses)
+		st.Evictions += atomic.LoadUint64(&sh.evictions)
+	}
+	st.Len = in.Len()
+	return st
+}
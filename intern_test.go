@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func stringData(s string) *byte {
+	return (*stringHeader)(unsafe.Pointer(&s)).P
+}
+
+func TestInterner(t *testing.T) {
+	for name, in := range map[string]*Interner{
+		"NewInterner": NewInterner(0),
+		"zero value":  new(Interner),
+	} {
+		if got := in.Stats(); got != (InternerStats{}) {
+			t.Errorf("%s: Stats of new Interner = %+v", name, got)
+		}
+		b := []byte("example.com")
+		s1 := in.Intern(B(b))
+		s2 := in.Intern(S("example.com"))
+		if s1 != "example.com" || stringData(s1) != stringData(s2) {
+			t.Fatalf("%s: Intern returned different strings %q, %q", name, s1, s2)
+		}
+
+		// The interned string must be a copy.
+		b[0] = 'E'
+		if s1 != "example.com" {
+			t.Fatalf("%s: interned string changed to %q", name, s1)
+		}
+
+		in.Intern(S("other"))
+		want := InternerStats{Len: 2, Hits: 1, Misses: 2}
+		if got := in.Stats(); got != want {
+			t.Errorf("%s: Stats = %+v; want %+v", name, got, want)
+		}
+	}
+}
+
+func TestInternerBounded(t *testing.T) {
+	const maxSize = 128
+	in := NewInterner(maxSize)
+	for i := 0; i < 10*maxSize; i++ {
+		in.Intern(S(fmt.Sprint(i)))
+	}
+	st := in.Stats()
+	if st.Len > maxSize+internShards {
+		t.Errorf("Len = %d; want about %d", st.Len, maxSize)
+	}
+	if st.Misses != 10*maxSize {
+		t.Errorf("Misses = %d; want %d", st.Misses, 10*maxSize)
+	}
+	if st.Evictions != st.Misses-uint64(st.Len) {
+		t.Errorf("Evictions = %d; want %d", st.Evictions, st.Misses-uint64(st.Len))
+	}
+}
+
+func TestInternerConcurrent(t *testing.T) {
+	var in Interner // the zero value initializes itself on first use
+	var wg sync.WaitGroup
+	got := make([][]string, 8)
+	for g := range got {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				got[g] = append(got[g], in.Intern(B([]byte(fmt.Sprint(i)))))
+			}
+		}(g)
+	}
+	wg.Wait()
+	for g := range got {
+		for i, s := range got[g] {
+			if stringData(s) != stringData(got[0][i]) {
+				t.Fatalf("goroutine %d got a different string for %q", g, s)
+			}
+		}
+	}
+	if st := in.Stats(); st.Len != 100 || st.Misses != 100 || st.Hits != 700 {
+		t.Errorf("Stats = %+v", st)
+	}
+}
+
+func TestInternerAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	in := NewInterner(0)
+	b := []byte("hostname")
+	in.Intern(B(b))
+	n := int(testing.AllocsPerRun(1000, func() {
+		globalString = in.Intern(B(b))
+	}))
+	if n != 0 {
+		t.Fatalf("allocs = %d; want 0", n)
+	}
+}
@@ -0,0 +1,159 @@
+//go:build go1.23
+// +build go1.23
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"iter"
+	"unicode"
+)
+
+// LinesSeq is like strings.Lines, but yields views of m instead of
+// strings. Each yielded line includes its terminating newline, if
+// any.
+func LinesSeq(m RO) iter.Seq[RO] {
+	return func(yield func(RO) bool) {
+		for m.Len() > 0 {
+			var line RO
+			if i := IndexByte(m, '\n'); i >= 0 {
+				line, m = m.SliceTo(i+1), m.SliceFrom(i+1)
+			} else {
+				line, m = m, RO{}
+			}
+			if !yield(line) {
+				return
+			}
+		}
+	}
+}
+
+// splitSeq is SplitSeq or SplitAfterSeq, configured by how many bytes
+// of sep to include in the results (none or all).
+func splitSeq(m, sep RO, sepSave int) iter.Seq[RO] {
+	return func(yield func(RO) bool) {
+		if sep.Len() == 0 {
+			// Split into UTF-8 sequences, like strings.Split does.
+			for m.Len() > 0 {
+				_, size := DecodeRune(m)
+				if !yield(m.SliceTo(size)) {
+					return
+				}
+				m = m.SliceFrom(size)
+			}
+			return
+		}
+		for {
+			i := Index(m, sep)
+			if i < 0 {
+				break
+			}
+			if !yield(m.SliceTo(i + sepSave)) {
+				return
+			}
+			m = m.SliceFrom(i + sep.Len())
+		}
+		yield(m)
+	}
+}
+
+// SplitSeq is like strings.SplitSeq, but yields views of m instead of
+// strings.
+func SplitSeq(m, sep RO) iter.Seq[RO] { return splitSeq(m, sep, 0) }
+
+// SplitAfterSeq is like strings.SplitAfterSeq, but yields views of m
+// instead of strings.
+func SplitAfterSeq(m, sep RO) iter.Seq[RO] { return splitSeq(m, sep, sep.Len()) }
+
+// FieldsSeq is like strings.FieldsSeq, but yields views of m instead
+// of strings. It uses the same ASCII fast path as AppendFields until
+// it meets a non-ASCII byte, and unicode.IsSpace from there on.
+func FieldsSeq(m RO) iter.Seq[RO] {
+	return func(yield func(RO) bool) {
+		s := m.m
+		i := 0
+		for {
+			start, end, ascii := nextASCIIField(s, i)
+			if !ascii {
+				i = start
+				break
+			}
+			if start == end || !yield(RO{d: m.d, m: s[start:end]}) {
+				return
+			}
+			i = end
+		}
+		// The rest, from the field holding the first non-ASCII byte.
+		FieldsFuncSeq(RO{d: m.d, m: s[i:]}, unicode.IsSpace)(yield)
+	}
+}
+
+// FieldsFuncSeq is like strings.FieldsFuncSeq, but yields views of m
+// instead of strings.
+func FieldsFuncSeq(m RO, f func(rune) bool) iter.Seq[RO] {
+	return func(yield func(RO) bool) {
+		s := string(m.m)
+		start := -1
+		for i, r := range s {
+			if f(r) {
+				if start >= 0 {
+					if !yield(RO{d: m.d, m: unsafeString(s[start:i])}) {
+						return
+					}
+					start = -1
+				}
+			} else if start < 0 {
+				start = i
+			}
+		}
+		if start >= 0 {
+			yield(RO{d: m.d, m: unsafeString(s[start:])})
+		}
+	}
+}
+
+// All returns an iterator over the keys and values in m, in
+// unspecified order. The map must not be modified during iteration.
+func (m *Map[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) { m.Range(yield) }
+}
+
+// All returns an iterator over the offsets in m of the
+// non-overlapping matches of sr's pattern, the ones counted by Count.
+// If the pattern is empty, it matches before each rune and at the end
+// of m.
+func (sr *Searcher) All(m RO) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if len(sr.pat) == 0 {
+			for i := range m.str() {
+				if !yield(i) {
+					return
+				}
+			}
+			yield(m.Len())
+			return
+		}
+		for off := 0; ; {
+			i, n := sr.index(m.SliceFrom(off))
+			if i < 0 || !yield(off+i) {
+				return
+			}
+			off += i + n
+		}
+	}
+}
@@ -0,0 +1,230 @@
+//go:build go1.23
+// +build go1.23
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func collect(seq iter.Seq[RO]) []RO {
+	var out []RO
+	for v := range seq {
+		out = append(out, v)
+	}
+	return out
+}
+
+var splitSeqTests = []struct {
+	s, sep string
+}{
+	{"", ""},
+	{"", ","},
+	{"abc", ""},
+	{"a,b,c", ","},
+	{"a,b,c,", ","},
+	{",a,,b", ","},
+	{"a--b--c", "--"},
+	{"☺☻☹", ""},
+	{"1\xFF2", ""},
+	{"no sep here", ","},
+}
+
+func TestSplitSeq(t *testing.T) {
+	for _, tt := range splitSeqTests {
+		got := collect(SplitSeq(S(tt.s), S(tt.sep)))
+		if want := strings.Split(tt.s, tt.sep); !eq(got, want) {
+			t.Errorf("SplitSeq(%q, %q) = %q; want %q", tt.s, tt.sep, strs(got), want)
+		}
+		got = collect(SplitAfterSeq(S(tt.s), S(tt.sep)))
+		if want := strings.SplitAfter(tt.s, tt.sep); !eq(got, want) {
+			t.Errorf("SplitAfterSeq(%q, %q) = %q; want %q", tt.s, tt.sep, strs(got), want)
+		}
+	}
+}
+
+func TestFieldsSeq(t *testing.T) {
+	for _, tt := range fieldstests {
+		if got := collect(FieldsSeq(S(tt.s))); !eq(got, tt.a) {
+			t.Errorf("FieldsSeq(%q) = %q; want %q", tt.s, strs(got), tt.a)
+		}
+		if got := collect(FieldsFuncSeq(S(tt.s), unicode.IsSpace)); !eq(got, tt.a) {
+			t.Errorf("FieldsFuncSeq(%q, unicode.IsSpace) = %q; want %q", tt.s, strs(got), tt.a)
+		}
+	}
+	// Non-ASCII text after some ASCII fields, which take the fast path.
+	for _, s := range []string{"ab  cd\u00a0ef  g\u3000h", "ab cdé fg", "ab \xff cd", "ab\u2000"} {
+		if got, want := collect(FieldsSeq(S(s))), strings.Fields(s); !eq(got, want) {
+			t.Errorf("FieldsSeq(%q) = %q; want %q", s, strs(got), want)
+		}
+	}
+	pred := func(c rune) bool { return c == 'X' }
+	for _, tt := range FieldsFuncTests {
+		if got := collect(FieldsFuncSeq(S(tt.s), pred)); !eq(got, tt.a) {
+			t.Errorf("FieldsFuncSeq(%q) = %q; want %q", tt.s, strs(got), tt.a)
+		}
+	}
+}
+
+func TestLinesSeq(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"\n", []string{"\n"}},
+		{"a", []string{"a"}},
+		{"a\nb", []string{"a\n", "b"}},
+		{"a\nb\n", []string{"a\n", "b\n"}},
+		{"a\r\n\nb", []string{"a\r\n", "\n", "b"}},
+	}
+	for _, tt := range tests {
+		if got := collect(LinesSeq(S(tt.s))); !eq(got, tt.want) {
+			t.Errorf("LinesSeq(%q) = %q; want %q", tt.s, strs(got), tt.want)
+		}
+	}
+}
+
+func TestSeqEarlyBreak(t *testing.T) {
+	seqs := map[string]iter.Seq[RO]{
+		"SplitSeq":      SplitSeq(S("a,b,c"), S(",")),
+		"SplitAfterSeq": SplitAfterSeq(S("a,b,c"), S(",")),
+		"explode":       SplitSeq(S("abc"), S("")),
+		"FieldsSeq":     FieldsSeq(S("a b c")),
+		"FieldsFuncSeq": FieldsFuncSeq(S("a b c"), unicode.IsSpace),
+		"LinesSeq":      LinesSeq(S("a\nb\nc")),
+	}
+	for name, seq := range seqs {
+		n := 0
+		for range seq {
+			n++
+			break
+		}
+		if n != 1 {
+			t.Errorf("%s: got %d iterations; want 1", name, n)
+		}
+	}
+}
+
+func TestSeqAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	b := []byte("foo bar\nbaz,qux\n")
+	n := int(testing.AllocsPerRun(1000, func() {
+		c := 0
+		for line := range LinesSeq(B(b)) {
+			for f := range FieldsSeq(line) {
+				for range SplitSeq(f, S(",")) {
+					c++
+				}
+			}
+		}
+		if c != 4 {
+			panic("wrong result")
+		}
+	}))
+	if n != 0 {
+		t.Fatalf("allocs = %d; want 0", n)
+	}
+}
+
+func TestSeqMemDebug(t *testing.T) {
+	if !memDebug {
+		t.Skip("needs the memdebug tag")
+	}
+	for name, seq := range map[string]func(RO) iter.Seq[RO]{
+		"FieldsSeq":        FieldsSeq,
+		"FieldsSeqUnicode": FieldsSeq,
+		"FieldsFuncSeq":    func(m RO) iter.Seq[RO] { return FieldsFuncSeq(m, unicode.IsSpace) },
+		"SplitSeq":         func(m RO) iter.Seq[RO] { return SplitSeq(m, S(" ")) },
+		"LinesSeq":         LinesSeq,
+	} {
+		text := "12 345"
+		if name == "FieldsSeqUnicode" {
+			text = "12\u00a0345"
+		}
+		b := []byte(text)
+		var last RO
+		for f := range seq(B(b)) {
+			last = f
+		}
+		last.StringCopy()
+
+		b[len(b)-1] = '0'
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic after mutating a view's bytes", name)
+				}
+			}()
+			last.StringCopy()
+		}()
+	}
+}
+
+func TestMapAll(t *testing.T) {
+	var m Map[int]
+	m.Set(S("a"), 1)
+	m.Set(S("b"), 2)
+	sum := 0
+	for k, v := range m.All() {
+		if k != "a" && k != "b" {
+			t.Errorf("unexpected key %q", k)
+		}
+		sum += v
+	}
+	if sum != 3 {
+		t.Errorf("sum = %d; want 3", sum)
+	}
+}
+
+func TestSearcherAll(t *testing.T) {
+	tests := []struct {
+		pat, s string
+		fold   bool
+		want   []int
+	}{
+		{"aa", "aaaaa", false, []int{0, 2}},
+		{"ab", "xabyab", false, []int{1, 4}},
+		{"k", "k K K", true, []int{0, 2, 4}},
+		{"", "a☺", false, []int{0, 1, 4}},
+		{"z", "abc", false, nil},
+	}
+	for _, tt := range tests {
+		sr := NewSearcher(S(tt.pat))
+		if tt.fold {
+			sr = NewSearcherFold(S(tt.pat))
+		}
+		var got []int
+		for i := range sr.All(S(tt.s)) {
+			got = append(got, i)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Searcher(%q).All(%q) = %v; want %v", tt.pat, tt.s, got, tt.want)
+		}
+		if len(got) != sr.Count(S(tt.s)) {
+			t.Errorf("Searcher(%q).All(%q) disagrees with Count", tt.pat, tt.s)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "syscall"
+
+func madvise(b []byte, a Advice) error {
+	var advice int
+	switch a {
+	case AdviceSequential:
+		advice = syscall.MADV_SEQUENTIAL
+	case AdviceRandom:
+		advice = syscall.MADV_RANDOM
+	case AdviceWillNeed:
+		advice = syscall.MADV_WILLNEED
+	default:
+		advice = syscall.MADV_NORMAL
+	}
+	return syscall.Madvise(b, advice)
+}
@@ -0,0 +1,160 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+// MapLookup returns m[k] and whether k was present in m.
+//
+// Unlike m[k.StringCopy()], it doesn't allocate. The map doesn't
+// retain the key used for a lookup, so the unsafe view of k never
+// escapes.
+func MapLookup[V any](m map[string]V, k RO) (v V, ok bool) {
+	v, ok = m[k.str()]
+	return
+}
+
+// MapDelete deletes k from m, without allocating.
+// If k isn't present in m, MapDelete is a no-op.
+func MapDelete[V any](m map[string]V, k RO) {
+	delete(m, k.str())
+}
+
+// Map is a hash map from string keys to values of type V that can be
+// accessed with RO keys.
+//
+// Keys are copied only when first inserted; lookups, updates and
+// deletes with an existing key don't allocate.
+//
+// The zero value is an empty map ready to use. A Map must not be
+// copied after first use, and is not safe for concurrent use.
+type Map[V any] struct {
+	fold    bool
+	n       int
+	buckets map[uint64][]mapEntry[V]
+}
+
+type mapEntry[V any] struct {
+	k string
+	v V
+}
+
+// NewMapFold returns a new, empty Map whose keys are compared using
+// Unicode case-folding, like EqualFold.
+//
+// A key's stored form is the one it was first inserted with.
+func NewMapFold[V any]() *Map[V] {
+	return &Map[V]{fold: true}
+}
+
+func (m *Map[V]) hash(k RO) uint64 {
+	if m.fold {
+		return k.MapHashFold()
+	}
+	return k.MapHash()
+}
+
+func (m *Map[V]) equal(k RO, s string) bool {
+	if m.fold {
+		return EqualFold(k, S(s))
+	}
+	return k.EqualString(s)
+}
+
+// find returns the bucket hash for k and the index of k within that
+// bucket, or -1 if k isn't present.
+func (m *Map[V]) find(k RO) (h uint64, i int) {
+	h = m.hash(k)
+	for i, e := range m.buckets[h] {
+		if m.equal(k, e.k) {
+			return h, i
+		}
+	}
+	return h, -1
+}
+
+// Len returns the number of keys in m.
+func (m *Map[V]) Len() int { return m.n }
+
+// Get returns the value for k and whether k was present in m.
+func (m *Map[V]) Get(k RO) (v V, ok bool) {
+	h, i := m.find(k)
+	if i < 0 {
+		return v, false
+	}
+	return m.buckets[h][i].v, true
+}
+
+// Has reports whether k is present in m.
+func (m *Map[V]) Has(k RO) bool {
+	_, i := m.find(k)
+	return i >= 0
+}
+
+// Set sets the value for k to v. If k isn't already present, a copy
+// of k is stored.
+func (m *Map[V]) Set(k RO, v V) {
+	h, i := m.find(k)
+	if i >= 0 {
+		m.buckets[h][i].v = v
+		return
+	}
+	if m.buckets == nil {
+		m.buckets = make(map[uint64][]mapEntry[V])
+	}
+	m.buckets[h] = append(m.buckets[h], mapEntry[V]{k: k.StringCopy(), v: v})
+	m.n++
+}
+
+// Delete removes k from m. If k isn't present, Delete is a no-op.
+func (m *Map[V]) Delete(k RO) {
+	h, i := m.find(k)
+	if i < 0 {
+		return
+	}
+	b := m.buckets[h]
+	last := len(b) - 1
+	b[i] = b[last]
+	b[last] = mapEntry[V]{}
+	if last == 0 {
+		delete(m.buckets, h)
+	} else {
+		m.buckets[h] = b[:last]
+	}
+	m.n--
+}
+
+// Clear removes all keys from m.
+func (m *Map[V]) Clear() {
+	m.buckets = nil
+	m.n = 0
+}
+
+// Range calls f for each key and value in m, in unspecified order.
+// If f returns false, Range stops the iteration.
+//
+// f must not modify m.
+func (m *Map[V]) Range(f func(k string, v V) bool) {
+	for _, b := range m.buckets {
+		for _, e := range b {
+			if !f(e.k, e.v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "testing"
+
+func TestMapLookup(t *testing.T) {
+	m := map[string]int{"foo": 1, "bar": 2}
+	b := []byte("foo")
+	if v, ok := MapLookup(m, B(b)); v != 1 || !ok {
+		t.Errorf("MapLookup(foo) = %v, %v; want 1, true", v, ok)
+	}
+	if v, ok := MapLookup(m, S("baz")); v != 0 || ok {
+		t.Errorf("MapLookup(baz) = %v, %v; want 0, false", v, ok)
+	}
+
+	// Mutating the lookup key's memory must not affect the map.
+	b[0] = 'g'
+	if _, ok := MapLookup(m, B(b)); ok {
+		t.Errorf("MapLookup(goo) found a value")
+	}
+	if _, ok := m["foo"]; !ok {
+		t.Errorf("map key changed underfoot")
+	}
+
+	MapDelete(m, B([]byte("bar")))
+	MapDelete(m, S("missing"))
+	if len(m) != 1 {
+		t.Errorf("len = %d after MapDelete; want 1", len(m))
+	}
+}
+
+func TestMapLookupAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	m := map[string]int{"some key": 1}
+	b := []byte("some key")
+	n := int(testing.AllocsPerRun(1000, func() {
+		if _, ok := MapLookup(m, B(b)); !ok {
+			panic("not found")
+		}
+		MapDelete(m, S("other key"))
+	}))
+	if n != 0 {
+		t.Fatalf("allocs = %d; want 0", n)
+	}
+}
+
+func TestMap(t *testing.T) {
+	var m Map[int]
+	if _, ok := m.Get(S("foo")); ok {
+		t.Fatal("zero Map has foo")
+	}
+	b := []byte("foo")
+	m.Set(B(b), 1)
+	m.Set(S("bar"), 2)
+	m.Set(S("foo"), 3)
+	if m.Len() != 2 {
+		t.Errorf("Len = %d; want 2", m.Len())
+	}
+
+	// The stored key must be a copy.
+	b[0] = 'g'
+	if v, ok := m.Get(S("foo")); v != 3 || !ok {
+		t.Errorf("Get(foo) = %v, %v; want 3, true", v, ok)
+	}
+	if m.Has(B(b)) {
+		t.Errorf("Has(goo) = true")
+	}
+	if m.Has(S("FOO")) {
+		t.Errorf("Has(FOO) = true on case-sensitive map")
+	}
+
+	m.Delete(S("foo"))
+	m.Delete(S("missing"))
+	if m.Has(S("foo")) || m.Len() != 1 {
+		t.Errorf("after Delete: Has(foo) = %v, Len = %d", m.Has(S("foo")), m.Len())
+	}
+
+	got := map[string]int{}
+	m.Range(func(k string, v int) bool {
+		got[k] = v
+		return true
+	})
+	if len(got) != 1 || got["bar"] != 2 {
+		t.Errorf("Range saw %v", got)
+	}
+
+	m.Clear()
+	if m.Len() != 0 || m.Has(S("bar")) {
+		t.Errorf("Clear didn't empty the map")
+	}
+}
+
+func TestMapFold(t *testing.T) {
+	m := NewMapFold[int]()
+	m.Set(S("Content-Type"), 1)
+	m.Set(S("CONTENT-TYPE"), 2)
+	m.Set(S("k"), 3)
+	m.Set(S("K"), 4) // KELVIN SIGN folds to k
+	m.Set(S("straße"), 5)
+	if m.Len() != 3 {
+		t.Errorf("Len = %d; want 3", m.Len())
+	}
+	for _, tt := range []struct {
+		k string
+		v int
+	}{
+		{"content-type", 2},
+		{"K", 4},
+		{"STRAßE", 5},
+		{"ſtraße", 5}, // LATIN SMALL LETTER LONG S folds to s
+	} {
+		if v, ok := m.Get(S(tt.k)); v != tt.v || !ok {
+			t.Errorf("Get(%q) = %v, %v; want %v, true", tt.k, v, ok, tt.v)
+		}
+	}
+	m.Range(func(k string, v int) bool {
+		if v == 2 && k != "Content-Type" {
+			t.Errorf("stored key = %q; want first inserted form", k)
+		}
+		return true
+	})
+}
+
+func TestMapAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	for _, m := range []*Map[int]{new(Map[int]), NewMapFold[int]()} {
+		m.Set(S("some key"), 1)
+		b := []byte("some key")
+		n := int(testing.AllocsPerRun(1000, func() {
+			m.Set(B(b), 2)
+			if _, ok := m.Get(B(b)); !ok {
+				panic("not found")
+			}
+		}))
+		if n != 0 {
+			t.Errorf("fold=%v: allocs = %d; want 0", m.fold, n)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+// Matcher finds occurrences of any of a fixed set of patterns in an
+// RO in a single pass over it, using the Aho-Corasick algorithm.
+//
+// A Matcher is immutable once built and safe for concurrent use.
+type Matcher struct {
+	fold     bool
+	patLen   []int       // pattern ID -> length
+	maxLen   int         // length of the longest pattern
+	class    [256]uint16 // byte -> byte class; 0 for bytes in no pattern
+	nclass   int         // number of byte classes, including 0
+	trans    []int32     // state*nclass + class -> next state
+	depth    []int32     // state -> length of the path to it from the root
+	out      [][]int32   // state -> IDs of patterns ending exactly there
+	dictLink []int32     // state -> nearest proper suffix state with output, or -1
+}
+
+// Match is a match of one of a Matcher's patterns.
+type Match struct {
+	Pattern    int // index of the pattern in the list given to NewMatcher
+	Start, End int // byte offsets of the match: m.Slice(Start, End)
+}
+
+// NewMatcher returns a Matcher for patterns. The patterns are copied;
+// empty patterns never match.
+func NewMatcher(patterns []RO) *Matcher {
+	return newMatcher(patterns, false)
+}
+
+// NewMatcherFoldASCII is like NewMatcher, but the returned Matcher
+// matches ASCII letters case-insensitively, like EqualFoldASCII.
+func NewMatcherFoldASCII(patterns []RO) *Matcher {
+	return newMatcher(patterns, true)
+}
+
+func newMatcher(patterns []RO, fold bool) *Matcher {
+	mt := &Matcher{fold: fold, patLen: make([]int, len(patterns))}
+
+	// Assign byte classes, so the transition table only needs a
+	// column per distinct byte used by the patterns.
+	mt.nclass = 1
+	for _, p := range patterns {
+		for i := 0; i < p.Len(); i++ {
+			c := mt.foldByte(p.At(i))
+			if mt.class[c] == 0 {
+				mt.class[c] = uint16(mt.nclass)
+				mt.nclass++
+			}
+		}
+	}
+	if fold {
+		for c := 'A'; c <= 'Z'; c++ {
+			mt.class[c] = mt.class[c+'a'-'A']
+		}
+	}
+
+	// Build the trie. -1 marks a missing transition.
+	mt.addState(0)
+	for id, p := range patterns {
+		mt.patLen[id] = p.Len()
+		if p.Len() == 0 {
+			continue
+		}
+		if p.Len() > mt.maxLen {
+			mt.maxLen = p.Len()
+		}
+		s := int32(0)
+		for i := 0; i < p.Len(); i++ {
+			ti := int(s)*mt.nclass + int(mt.class[p.At(i)])
+			if mt.trans[ti] < 0 {
+				t := mt.addState(mt.depth[s] + 1)
+				mt.trans[ti] = t
+			}
+			s = mt.trans[ti]
+		}
+		mt.out[s] = append(mt.out[s], int32(id))
+	}
+
+	// Compute failure links breadth-first, turning the trie into a
+	// DFA by filling in each missing transition from the failure
+	// state's.
+	fail := make([]int32, len(mt.depth))
+	queue := make([]int32, 0, len(mt.depth))
+	for c := 0; c < mt.nclass; c++ {
+		t := &mt.trans[c]
+		if *t < 0 {
+			*t = 0
+		} else {
+			queue = append(queue, *t)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		f := fail[s]
+		if len(mt.out[f]) > 0 {
+			mt.dictLink[s] = f
+		} else {
+			mt.dictLink[s] = mt.dictLink[f]
+		}
+		row := mt.trans[int(s)*mt.nclass : int(s+1)*mt.nclass]
+		frow := mt.trans[int(f)*mt.nclass : int(f+1)*mt.nclass]
+		for c, t := range row {
+			if t < 0 {
+				row[c] = frow[c]
+			} else {
+				fail[t] = frow[c]
+				queue = append(queue, t)
+			}
+		}
+	}
+	return mt
+}
+
+// addState adds a state at the given depth and returns its number.
+func (mt *Matcher) addState(depth int32) int32 {
+	s := int32(len(mt.depth))
+	for c := 0; c < mt.nclass; c++ {
+		mt.trans = append(mt.trans, -1)
+	}
+	mt.depth = append(mt.depth, depth)
+	mt.out = append(mt.out, nil)
+	mt.dictLink = append(mt.dictLink, -1)
+	return s
+}
+
+func (mt *Matcher) foldByte(c byte) byte {
+	if mt.fold {
+		return asciiLower[c]
+	}
+	return c
+}
+
+// next returns the state following s on byte c.
+func (mt *Matcher) next(s int32, c byte) int32 {
+	return mt.trans[int(s)*mt.nclass+int(mt.class[c])]
+}
+
+// Len returns the number of patterns mt was built with.
+func (mt *Matcher) Len() int { return len(mt.patLen) }
+
+// AppendAll appends to dst all matches of mt's patterns in m,
+// including overlapping ones, and returns the extended slice. Matches
+// are ordered by their end offset, and longer matches come first
+// among those ending at the same offset.
+func (mt *Matcher) AppendAll(dst []Match, m RO) []Match {
+	s := int32(0)
+	for i := 0; i < m.Len(); i++ {
+		s = mt.next(s, m.At(i))
+		for o := s; o > 0; o = mt.dictLink[o] {
+			for _, id := range mt.out[o] {
+				dst = append(dst, Match{Pattern: int(id), Start: i + 1 - mt.patLen[id], End: i + 1})
+			}
+		}
+	}
+	return dst
+}
+
+// First returns the match in m that ends first, preferring the
+// longest among those ending at the same offset. It stops scanning m
+// as soon as it finds one.
+func (mt *Matcher) First(m RO) (Match, bool) {
+	s := int32(0)
+	for i := 0; i < m.Len(); i++ {
+		s = mt.next(s, m.At(i))
+		o := s
+		if len(mt.out[o]) == 0 {
+			o = mt.dictLink[o]
+		}
+		if o > 0 {
+			id := mt.out[o][0]
+			return Match{Pattern: int(id), Start: i + 1 - mt.patLen[id], End: i + 1}, true
+		}
+	}
+	return Match{}, false
+}
+
+// AppendLeftmostLongest appends to dst the non-overlapping matches of
+// mt's patterns in m and returns the extended slice. Scanning from
+// the start of m, it picks the match that starts first, preferring
+// the longest among those starting at the same offset, then continues
+// after it. Like AppendAll, it makes a single pass over m.
+func (mt *Matcher) AppendLeftmostLongest(dst []Match, m RO) []Match {
+	if mt.maxLen == 0 {
+		return dst
+	}
+	// pending holds the longest match seen so far starting at each
+	// offset that a match could still start at, indexed by the offset
+	// modulo maxLen. An End of 0 marks an empty slot.
+	var buf [32]Match
+	pending := buf[:]
+	if mt.maxLen > len(buf) {
+		pending = make([]Match, mt.maxLen)
+	}
+	pending = pending[:mt.maxLen]
+
+	pos := 0 // where the next match may start
+	s := int32(0)
+	for i := 0; i < m.Len(); i++ {
+		s = mt.next(s, m.At(i))
+		// Any match from here on starts at or after i+1-depth, so
+		// pending matches starting before that can't be beaten.
+		dst, pos = mt.flushPending(dst, pending, pos, i+1-int(mt.depth[s]))
+		for o := s; o > 0; o = mt.dictLink[o] {
+			if len(mt.out[o]) == 0 {
+				continue
+			}
+			id := mt.out[o][0]
+			start := i + 1 - mt.patLen[id]
+			if start < pos {
+				continue
+			}
+			// Later matches with the same start are longer.
+			pending[start%mt.maxLen] = Match{Pattern: int(id), Start: start, End: i + 1}
+		}
+	}
+	dst, _ = mt.flushPending(dst, pending, pos, m.Len())
+	return dst
+}
+
+// flushPending appends to dst, in order, the leftmost-longest matches
+// in pending that start at or after pos and before lim, clearing the
+// slots it passes over, and returns the extended slice and the offset
+// where the next match may start.
+func (mt *Matcher) flushPending(dst, pending []Match, pos, lim int) ([]Match, int) {
+	for pos < lim {
+		p := &pending[pos%mt.maxLen]
+		if p.End == 0 {
+			pos++
+			continue
+		}
+		match := *p
+		dst = append(dst, match)
+		for ; pos < match.End; pos++ {
+			pending[pos%mt.maxLen] = Match{}
+		}
+	}
+	return dst, pos
+}
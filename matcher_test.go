@@ -0,0 +1,190 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// naiveMatches returns all matches of patterns in m, in the order
+// documented for Matcher.AppendAll.
+func naiveMatches(patterns []RO, m RO, fold bool) []Match {
+	var all []Match
+	for id, p := range patterns {
+		if p.Len() == 0 {
+			continue
+		}
+		for i := 0; i+p.Len() <= m.Len(); i++ {
+			w := m.Slice(i, i+p.Len())
+			if w.Equal(p) || fold && EqualFoldASCII(w, p) {
+				all = append(all, Match{Pattern: id, Start: i, End: i + p.Len()})
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.End != b.End {
+			return a.End < b.End
+		}
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return a.Pattern < b.Pattern
+	})
+	return all
+}
+
+// naiveLeftmostLongest filters all, as returned by naiveMatches, to
+// the non-overlapping leftmost-longest matches.
+func naiveLeftmostLongest(all []Match) []Match {
+	var out []Match
+	pos := 0
+	for {
+		best := Match{Start: -1}
+		for _, m := range all {
+			if m.Start < pos {
+				continue
+			}
+			if best.Start < 0 || m.Start < best.Start || m.Start == best.Start && m.End > best.End {
+				best = m
+			}
+		}
+		if best.Start < 0 {
+			return out
+		}
+		out = append(out, best)
+		pos = best.End
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	patterns := []RO{S("he"), S("she"), S("his"), S("hers"), S("")}
+	mt := NewMatcher(patterns)
+	m := S("ushers and his sheep")
+	got := mt.AppendAll(nil, m)
+	want := []Match{
+		{1, 1, 4}, {0, 2, 4}, {3, 2, 6}, {2, 11, 14}, {1, 15, 18}, {0, 16, 18},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AppendAll = %v; want %v", got, want)
+	}
+	got = mt.AppendLeftmostLongest(nil, m)
+	want = []Match{{1, 1, 4}, {2, 11, 14}, {1, 15, 18}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AppendLeftmostLongest = %v; want %v", got, want)
+	}
+	if first, ok := mt.First(m); !ok || first != (Match{1, 1, 4}) {
+		t.Errorf("First = %v, %v", first, ok)
+	}
+	if _, ok := mt.First(S("nothing to see")); ok {
+		t.Errorf("First found a match in text without one")
+	}
+
+	fm := NewMatcherFoldASCII([]RO{S("content-type"), S("HOST")})
+	got = fm.AppendAll(nil, S("Host: x\r\nCONTENT-TYPE: y"))
+	want = []Match{{1, 0, 4}, {0, 9, 21}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fold AppendAll = %v; want %v", got, want)
+	}
+}
+
+func TestMatcherRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randString := func(n int) RO {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "abcAB\xff"[r.Intn(6)]
+		}
+		return B(b)
+	}
+	for iter := 0; iter < 500; iter++ {
+		patterns := make([]RO, 1+r.Intn(8))
+		for i := range patterns {
+			patterns[i] = randString(r.Intn(5))
+		}
+		m := randString(r.Intn(40))
+		for _, fold := range []bool{false, true} {
+			mt := NewMatcher(patterns)
+			if fold {
+				mt = NewMatcherFoldASCII(patterns)
+			}
+			all := naiveMatches(patterns, m, fold)
+
+			got := mt.AppendAll(nil, m)
+			if len(got) != len(all) || len(got) > 0 && !reflect.DeepEqual(got, all) {
+				t.Fatalf("fold=%v, patterns %q, m %q: AppendAll = %v; want %v", fold, strs(patterns), m.StringCopy(), got, all)
+			}
+
+			ll := mt.AppendLeftmostLongest(nil, m)
+			if want := naiveLeftmostLongest(all); len(ll) != len(want) || len(ll) > 0 && !reflect.DeepEqual(ll, want) {
+				t.Fatalf("fold=%v, patterns %q, m %q: AppendLeftmostLongest = %v; want %v", fold, strs(patterns), m.StringCopy(), ll, want)
+			}
+
+			first, ok := mt.First(m)
+			if ok != (len(all) > 0) || ok && first != all[0] {
+				t.Fatalf("fold=%v, patterns %q, m %q: First = %v, %v", fold, strs(patterns), m.StringCopy(), first, ok)
+			}
+		}
+	}
+}
+
+// longOverlapPatterns returns patterns where every short match is
+// followed by a long prefix of a pattern that never completes, the
+// worst case for leftmost-longest lookahead.
+func longOverlapPatterns(n int) []RO {
+	return []RO{S("a"), S("ab"), S(strings.Repeat("a", n) + "b"), S(strings.Repeat("ba", n) + "c")}
+}
+
+func TestMatcherLeftmostLongestLong(t *testing.T) {
+	patterns := longOverlapPatterns(50)
+	m := S(strings.Repeat("a", 120) + "b" + strings.Repeat("ba", 60) + "x" + strings.Repeat("a", 49) + "b")
+	mt := NewMatcher(patterns)
+	got := mt.AppendLeftmostLongest(nil, m)
+	if want := naiveLeftmostLongest(naiveMatches(patterns, m, false)); !reflect.DeepEqual(got, want) {
+		t.Errorf("AppendLeftmostLongest = %v; want %v", got, want)
+	}
+}
+
+func TestMatcherAllocs(t *testing.T) {
+	mt := NewMatcher([]RO{S("error"), S("warning"), S("panic")})
+	m := B([]byte("2020/01/01 something went wrong: panic: oops"))
+	buf := make([]Match, 0, 8)
+	n := int(testing.AllocsPerRun(1000, func() {
+		buf = mt.AppendAll(buf[:0], m)
+		buf = mt.AppendLeftmostLongest(buf, m)
+		if _, ok := mt.First(m); !ok {
+			panic("no match")
+		}
+	}))
+	if n != 0 {
+		t.Fatalf("allocs = %d; want 0", n)
+	}
+}
+
+func BenchmarkMatcherLeftmostLongest(b *testing.B) {
+	mt := NewMatcher(longOverlapPatterns(100))
+	m := S(strings.Repeat("a", 64<<10))
+	b.SetBytes(int64(m.Len()))
+	var buf []Match
+	for i := 0; i < b.N; i++ {
+		buf = mt.AppendLeftmostLongest(buf[:0], m)
+	}
+}
@@ -19,7 +19,9 @@ limitations under the License.
 package mem // import "go4.org/mem"
 
 import (
+	"errors"
 	"hash/maphash"
+	"io"
 	"strconv"
 	"strings"
 	"sync"
@@ -43,6 +45,7 @@ import (
 // Go's map implementation.
 type RO struct {
 	_ [0]func() // not comparable; don't want to be a map key or support ==
+	d roDebug   // zero-sized unless built with the memdebug tag; see memdebug.go
 	m unsafeString
 }
 
@@ -59,13 +62,13 @@ func (r RO) Len() int { return len(r.m) }
 func (r RO) At(i int) byte { return r.m[i] }
 
 // Slice returns r[from:to].
-func (r RO) Slice(from, to int) RO { return RO{m: r.m[from:to]} }
+func (r RO) Slice(from, to int) RO { return RO{d: r.d, m: r.m[from:to]} }
 
 // SliceFrom returns r[from:].
-func (r RO) SliceFrom(from int) RO { return RO{m: r.m[from:]} }
+func (r RO) SliceFrom(from int) RO { return RO{d: r.d, m: r.m[from:]} }
 
 // SliceTo returns r[:to].
-func (r RO) SliceTo(to int) RO { return RO{m: r.m[:to]} }
+func (r RO) SliceTo(to int) RO { return RO{d: r.d, m: r.m[:to]} }
 
 // Copy copies up to len(dest) bytes into dest from r and returns the
 // number of bytes copied, the min(r.Len(), len(dest)).
@@ -73,19 +76,35 @@ func (r RO) Copy(dest []byte) int { return copy(dest, r.m) }
 
 // Equal reports whether r and r2 are the same length and contain the
 // same bytes.
-func (r RO) Equal(r2 RO) bool { return r.m == r2.m }
+func (r RO) Equal(r2 RO) bool {
+	r.check()
+	r2.check()
+	return r.m == r2.m
+}
 
 // EqualString reports whether r and s are the same length and contain
 // the same bytes.
-func (r RO) EqualString(s string) bool { return r.str() == s }
+func (r RO) EqualString(s string) bool {
+	r.check()
+	return r.str() == s
+}
 
 // EqualBytes reports whether r and b are the same length and contain
 // the same bytes.
-func (r RO) EqualBytes(b []byte) bool { return r.str() == string(b) }
+func (r RO) EqualBytes(b []byte) bool {
+	r.check()
+	return r.str() == string(b)
+}
 
 // Less reports whether r < r2.
 func (r RO) Less(r2 RO) bool { return r.str() < r2.str() }
 
+// Compare returns an integer comparing a and b lexicographically.
+// The result is 0 if a == b, -1 if a < b, and +1 if a > b.
+//
+// Its signature suits slices.SortFunc and slices.BinarySearchFunc.
+func Compare(a, b RO) int { return strings.Compare(a.str(), b.str()) }
+
 var builderPool = sync.Pool{
 	New: func() interface{} {
 		return new(strings.Builder)
@@ -94,6 +113,7 @@ var builderPool = sync.Pool{
 
 // StringCopy returns m's contents in a newly allocated string.
 func (r RO) StringCopy() string {
+	r.check()
 	buf := builderPool.Get().(*strings.Builder)
 	defer builderPool.Put(buf)
 	defer buf.Reset()
@@ -106,6 +126,7 @@ var seed = maphash.MakeSeed()
 // MapHash returns a hash of r's contents using runtime/maphash.
 // The hash is stable for the lifetime of a process.
 func (r RO) MapHash() uint64 {
+	r.check()
 	var hash maphash.Hash
 	hash.SetSeed(seed)
 	hash.WriteString(r.str())
@@ -113,23 +134,53 @@ func (r RO) MapHash() uint64 {
 }
 
 // ParseInt returns a signed integer from m, using strconv.ParseInt.
+// See TryParseInt for a variant whose errors don't allocate.
 func ParseInt(m RO, base, bitSize int) (int64, error) {
-	return strconv.ParseInt(m.str(), base, bitSize)
+	m.check()
+	v, err := strconv.ParseInt(m.str(), base, bitSize)
+	return v, ownNumError(err, m)
 }
 
 // ParseUint returns a unsigned integer from m, using strconv.ParseUint.
+// See TryParseUint for a variant whose errors don't allocate.
 func ParseUint(m RO, base, bitSize int) (uint64, error) {
-	return strconv.ParseUint(m.str(), base, bitSize)
+	m.check()
+	v, err := strconv.ParseUint(m.str(), base, bitSize)
+	return v, ownNumError(err, m)
 }
 
 // ParseFloat returns a float from, using strconv.ParseFloat.
+// See TryParseFloat for a variant whose errors don't allocate.
 func ParseFloat(m RO, bitSize int) (float64, error) {
-	return strconv.ParseFloat(m.str(), bitSize)
+	m.check()
+	v, err := strconv.ParseFloat(m.str(), bitSize)
+	return v, ownNumError(err, m)
+}
+
+// ParseBool returns the boolean value represented by m, using
+// strconv.ParseBool.
+func ParseBool(m RO) (bool, error) {
+	m.check()
+	v, err := strconv.ParseBool(m.str())
+	return v, ownNumError(err, m)
+}
+
+// ownNumError makes sure an error from strconv doesn't refer to m's
+// memory, which the caller may later modify. Not all versions of
+// strconv copy the input into NumError.Num.
+func ownNumError(err error, m RO) error {
+	if ne, ok := err.(*strconv.NumError); ok {
+		ne.Num = m.StringCopy()
+	}
+	return err
 }
 
 // Append appends m to dest, and returns the possibly-reallocated
 // dest.
-func Append(dest []byte, m RO) []byte { return append(dest, m.m...) }
+func Append(dest []byte, m RO) []byte {
+	m.check()
+	return append(dest, m.m...)
+}
 
 // Contains reports whether substr is within m.
 func Contains(m, substr RO) bool { return strings.Contains(m.str(), substr.str()) }
@@ -262,7 +313,7 @@ func ValidUTF8(m RO) bool {
 
 // NewReader returns a new Reader that reads from m.
 func NewReader(m RO) *Reader {
-	return &Reader{sr: strings.NewReader(m.str())}
+	return &Reader{m: m, sr: strings.NewReader(m.str())}
 }
 
 // Cut works like strings.Cut, but takes and returns ROs.
@@ -291,6 +342,7 @@ func CutSuffix(m, suffix RO) (before RO, found bool) {
 
 // Reader is like a bytes.Reader or strings.Reader.
 type Reader struct {
+	m  RO // the whole input
 	sr *strings.Reader
 }
 
@@ -301,8 +353,118 @@ func (r *Reader) ReadAt(b []byte, off int64) (int, error)      { return r.sr.Rea
 func (r *Reader) ReadByte() (byte, error)                      { return r.sr.ReadByte() }
 func (r *Reader) ReadRune() (ch rune, size int, err error)     { return r.sr.ReadRune() }
 func (r *Reader) Seek(offset int64, whence int) (int64, error) { return r.sr.Seek(offset, whence) }
+func (r *Reader) UnreadByte() error                            { return r.sr.UnreadByte() }
+func (r *Reader) UnreadRune() error                            { return r.sr.UnreadRune() }
+
+// Reset resets r to be reading from m, so a Reader can be reused.
+// It's valid to call Reset on a zero Reader.
+func (r *Reader) Reset(m RO) {
+	if r.sr == nil {
+		r.sr = new(strings.Reader)
+	}
+	r.m = m
+	r.sr.Reset(m.str())
+}
+
+// The following methods return views of the Reader's underlying
+// memory rather than copying it. They're valid for as long as that
+// memory is.
+
+// Remaining returns the unread portion of r, without advancing.
+func (r *Reader) Remaining() RO { return r.m.SliceFrom(r.m.Len() - r.sr.Len()) }
+
+// skip advances r by n bytes. n must be at most r.Len().
+func (r *Reader) skip(n int) { r.sr.Seek(int64(n), io.SeekCurrent) }
+
+// Peek returns the next n unread bytes without advancing r.
+// If fewer than n bytes remain, Peek returns all of them.
+// A negative n is treated as zero.
+func (r *Reader) Peek(n int) RO {
+	rest := r.Remaining()
+	if n < 0 {
+		n = 0
+	}
+	if n < rest.Len() {
+		rest = rest.SliceTo(n)
+	}
+	return rest
+}
+
+// ReadRO returns the next n unread bytes and advances r past them.
+// If fewer than n bytes remain, ReadRO returns all of them.
+// A negative n is treated as zero.
+func (r *Reader) ReadRO(n int) RO {
+	v := r.Peek(n)
+	r.skip(v.Len())
+	return v
+}
+
+// ReadUntil reads until the first occurrence of delim, returning the
+// bytes read up to and including delim. If delim isn't found,
+// ReadUntil returns all remaining bytes and io.EOF.
+func (r *Reader) ReadUntil(delim byte) (RO, error) {
+	rest := r.Remaining()
+	i := IndexByte(rest, delim)
+	if i < 0 {
+		r.skip(rest.Len())
+		return rest, io.EOF
+	}
+	r.skip(i + 1)
+	return rest.SliceTo(i + 1), nil
+}
+
+// ReadLine reads the next line, returning it without its trailing
+// "\n" or "\r\n". The last line need not end in a newline. If no
+// bytes remain, ReadLine returns an empty RO and io.EOF.
+func (r *Reader) ReadLine() (RO, error) {
+	if r.Len() == 0 {
+		return S(""), io.EOF
+	}
+	line, _ := r.ReadUntil('\n')
+	line = TrimSuffix(line, S("\n"))
+	line = TrimSuffix(line, S("\r"))
+	return line, nil
+}
+
+var copyBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 32<<10)
+		return &b
+	},
+}
+
+// WriteTo implements io.WriterTo.
+//
+// Unlike strings.Reader.WriteTo, it never passes the underlying
+// memory to w. It copies through a pooled buffer instead, so w can't
+// retain a reference to memory that might change underfoot.
+func (r *Reader) WriteTo(w io.Writer) (n int64, err error) {
+	bp := copyBufPool.Get().(*[]byte)
+	defer copyBufPool.Put(bp)
+	buf := *bp
+	for r.sr.Len() > 0 {
+		nr, _ := r.sr.Read(buf)
+		nw, err := w.Write(buf[:nr])
+		if nw < 0 || nw > nr {
+			nw = 0
+			if err == nil {
+				err = errInvalidWrite
+			}
+		}
+		n += int64(nw)
+		if err == nil && nw != nr {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			// Un-read what w didn't accept.
+			r.sr.Seek(int64(nw-nr), io.SeekCurrent)
+			return n, err
+		}
+	}
+	return n, nil
+}
 
-// TODO: add Reader.WriteTo, but don't use strings.Reader.WriteTo because it uses io.WriteString, leaking our unsafe string
+var errInvalidWrite = errors.New("invalid write result")
 
 // unsafeString is a string that's not really a Go string.
 // It might be pointing into a []byte. Don't let it escape to callers.
@@ -333,5 +495,6 @@ func B(b []byte) RO {
 	if len(b) == 0 {
 		return RO{m: ""}
 	}
-	return RO{m: *(*unsafeString)(unsafe.Pointer(&stringHeader{&b[0], len(b)}))}
+	m := *(*unsafeString)(unsafe.Pointer(&stringHeader{&b[0], len(b)}))
+	return RO{d: newRODebug(m, nil), m: m}
 }
@@ -16,7 +16,13 @@ limitations under the License.
 
 package mem
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"unsafe"
+)
 
 func TestRO(t *testing.T) {
 	b := []byte("some memory.")
@@ -51,6 +57,10 @@ func TestRO(t *testing.T) {
 		t.Errorf("bad less")
 	}
 
+	if Compare(rb, rs) != 0 || Compare(rs, S("~")) != -1 || Compare(S("~"), rb) != +1 {
+		t.Errorf("bad compare")
+	}
+
 	if rb.At(0) != 's' {
 		t.Fatalf("[0] = %q; want 's'", rb.At(0))
 	}
@@ -58,6 +68,10 @@ func TestRO(t *testing.T) {
 	if rb.At(0) != 'z' {
 		t.Fatalf("[0] = %q; want 'z'", rb.At(0))
 	}
+	if memDebug {
+		// Reading the mutated view would panic; see memdebug.go.
+		rb = B(b)
+	}
 
 	var got []byte
 	got = Append(got, rb)
@@ -77,7 +91,7 @@ func TestAllocs(t *testing.T) {
 			t.Fatal("wrong length")
 		}
 	}))
-	if n != 0 {
+	if n != 0 && !memDebug {
 		t.Errorf("B: unexpected allocs (%d)", n)
 	}
 
@@ -96,6 +110,15 @@ func TestAllocs(t *testing.T) {
 
 var globalString string
 
+func TestSize(t *testing.T) {
+	if memDebug {
+		t.Skip("RO is larger in memdebug builds")
+	}
+	if got, want := unsafe.Sizeof(RO{}), unsafe.Sizeof(""); got != want {
+		t.Errorf("RO is %d bytes; want %d, the size of a string", got, want)
+	}
+}
+
 func TestStrconv(t *testing.T) {
 	b := []byte("1234")
 	i, err := ParseInt(B(b), 10, 64)
@@ -173,6 +196,168 @@ func TestCutSuffix(t *testing.T) {
 	}
 }
 
+// checkWriter records what's written to it and the addresses of the
+// slices it was passed.
+type checkWriter struct {
+	got   []byte
+	addrs []uintptr
+	max   int // max bytes to accept per Write, if non-zero
+}
+
+func (w *checkWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.addrs = append(w.addrs, addr(p))
+	}
+	w.got = append(w.got, p...)
+	if w.max > 0 && len(p) > w.max {
+		return w.max, errors.New("short")
+	}
+	return len(p), nil
+}
+
+func addr(b []byte) uintptr { return uintptr(unsafe.Pointer(&b[0])) }
+
+func TestReaderWriteTo(t *testing.T) {
+	b := bytes.Repeat([]byte("0123456789"), 10000)
+	r := NewReader(B(b))
+	r.ReadByte()
+	w := new(checkWriter)
+	n, err := r.WriteTo(w)
+	if err != nil || n != int64(len(b)-1) {
+		t.Fatalf("WriteTo = %d, %v; want %d, nil", n, err, len(b)-1)
+	}
+	if r.Len() != 0 {
+		t.Errorf("Len after WriteTo = %d; want 0", r.Len())
+	}
+	for _, a := range w.addrs {
+		if a >= addr(b) && a < addr(b)+uintptr(len(b)) {
+			t.Fatal("WriteTo passed the underlying memory to the writer")
+		}
+	}
+	if !bytes.Equal(w.got, b[1:]) {
+		t.Error("WriteTo wrote the wrong bytes")
+	}
+
+	r.Reset(B(b))
+	n, err = r.WriteTo(&checkWriter{max: 100})
+	if n != 100 || err == nil {
+		t.Errorf("short WriteTo = %d, %v; want 100, error", n, err)
+	}
+	if r.Len() != len(b)-100 {
+		t.Errorf("Len after short WriteTo = %d; want %d", r.Len(), len(b)-100)
+	}
+}
+
+func TestReaderWriteToAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	b := []byte("some memory.")
+	var r Reader
+	var buf bytes.Buffer
+	buf.Grow(len(b))
+	n := int(testing.AllocsPerRun(1000, func() {
+		r.Reset(B(b))
+		buf.Reset()
+		io.Copy(&buf, &r)
+	}))
+	if n != 0 {
+		t.Fatalf("allocs = %d; want 0", n)
+	}
+	if buf.String() != string(b) {
+		t.Errorf("copied %q; want %q", buf.String(), b)
+	}
+}
+
+func TestReaderUnread(t *testing.T) {
+	var r Reader
+	r.Reset(S("é!"))
+	c, size, err := r.ReadRune()
+	if c != 'é' || size != 2 || err != nil {
+		t.Fatalf("ReadRune = %q, %d, %v", c, size, err)
+	}
+	if err := r.UnreadRune(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 3 {
+		t.Errorf("Len after UnreadRune = %d; want 3", r.Len())
+	}
+	if err := r.UnreadRune(); err == nil {
+		t.Error("second UnreadRune succeeded")
+	}
+	r.ReadRune()
+	b, _ := r.ReadByte()
+	if b != '!' {
+		t.Fatalf("ReadByte = %q; want '!'", b)
+	}
+	if err := r.UnreadByte(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := r.ReadByte(); b != '!' {
+		t.Errorf("ReadByte after UnreadByte = %q; want '!'", b)
+	}
+	var _ io.RuneScanner = &r
+}
+
+func TestReaderViews(t *testing.T) {
+	b := []byte("GET / HTTP/1.1\r\nHost: foo\n\nbody")
+	r := NewReader(B(b))
+	if v := r.Peek(3); !v.EqualString("GET") || r.Len() != len(b) {
+		t.Fatalf("Peek(3) = %q, Len = %d", v.StringCopy(), r.Len())
+	}
+	if v := r.ReadRO(3); !v.EqualString("GET") {
+		t.Fatalf("ReadRO(3) = %q", v.StringCopy())
+	}
+	if v := r.Peek(-1); v.Len() != 0 {
+		t.Fatalf("Peek(-1) = %q; want empty", v.StringCopy())
+	}
+	if v := r.ReadRO(-1); v.Len() != 0 || r.Len() != len(b)-3 {
+		t.Fatalf("ReadRO(-1) = %q, Len = %d; want empty and not advanced", v.StringCopy(), r.Len())
+	}
+	if v, err := r.ReadUntil('/'); !v.EqualString(" /") || err != nil {
+		t.Fatalf("ReadUntil('/') = %q, %v", v.StringCopy(), err)
+	}
+	for _, want := range []string{" HTTP/1.1", "Host: foo", ""} {
+		if v, err := r.ReadLine(); !v.EqualString(want) || err != nil {
+			t.Fatalf("ReadLine = %q, %v; want %q, nil", v.StringCopy(), err, want)
+		}
+	}
+	if v := r.Remaining(); !v.EqualString("body") {
+		t.Fatalf("Remaining = %q", v.StringCopy())
+	}
+
+	// Views alias the underlying memory.
+	last := "body"
+	if !memDebug { // mutating would panic; see memdebug.go
+		b[len(b)-1] = 'Y'
+		last = "bodY"
+	}
+	if v := r.Peek(100); !v.EqualString(last) {
+		t.Fatalf("Peek(100) = %q", v.StringCopy())
+	}
+
+	if v, err := r.ReadUntil('\n'); !v.EqualString(last) || err != io.EOF {
+		t.Fatalf("ReadUntil at end = %q, %v; want %q, EOF", v.StringCopy(), err, last)
+	}
+	if v, err := r.ReadLine(); v.Len() != 0 || err != io.EOF {
+		t.Fatalf("ReadLine at EOF = %q, %v", v.StringCopy(), err)
+	}
+	if v := r.ReadRO(1); v.Len() != 0 {
+		t.Fatalf("ReadRO at EOF = %q", v.StringCopy())
+	}
+
+	// Mixing with copying reads.
+	r.Reset(S("abc"))
+	r.ReadByte()
+	if v := r.ReadRO(1); !v.EqualString("b") {
+		t.Fatalf("ReadRO after ReadByte = %q", v.StringCopy())
+	}
+	r.Seek(10, io.SeekStart)
+	if v := r.Remaining(); v.Len() != 0 {
+		t.Fatalf("Remaining after seeking past end = %q", v.StringCopy())
+	}
+}
+
 func BenchmarkStringCopy(b *testing.B) {
 	b.ReportAllocs()
 	ro := S("only a fool starts a large fire.")
@@ -0,0 +1,152 @@
+//go:build memdebug
+// +build memdebug
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"fmt"
+	"hash/maphash"
+	"runtime"
+	"strings"
+	"unsafe"
+)
+
+// When built with the memdebug tag, an RO created by B remembers a
+// checksum of the bytes it viewed and where B was called. Methods
+// that read an RO's contents, such as Equal, MapHash, StringCopy and
+// the Parse functions, verify the checksum and panic if the memory
+// was modified after the view was created. Views derived from it, with
+// Slice, AppendFields and the like, are checked too, but only notice
+// changes near the bytes they cover.
+//
+// Views returned by a Buffer or an Arena also panic if used after the
+// Buffer or Arena is Reset, or the Buffer Truncated.
+//
+// Views of a MappedFile aren't checksummed, as the mapping can't be
+// written to, but they panic if used after the file is Closed.
+//
+// A check only rehashes the blocks of memory the checked view covers,
+// but the memdebug tag still makes RO larger and B hash all the bytes
+// it's given, so it's only meant for tests:
+//
+//	go test -tags memdebug ./...
+
+// memDebug reports whether the package was built with the memdebug
+// tag.
+const memDebug = true
+
+type roDebug struct {
+	info *roDebugInfo
+}
+
+type roDebugInfo struct {
+	orig  unsafeString // the whole view created by B
+	sums  []uint64     // checksum of each debugBlock of orig; nil if unchecked
+	stack []uintptr    // where B was called
+
+	// For views of a Buffer, Arena or MappedFile, its generation
+	// counter and the counter's value when the view was created.
+	gen     *uint64
+	wantGen uint64
+}
+
+// debugBlock is the size of the blocks of a view that are checksummed
+// separately, so checking a small view of a large one is cheap.
+const debugBlock = 1 << 10
+
+var debugSeed = maphash.MakeSeed()
+
+func debugSum(m unsafeString) uint64 {
+	var h maphash.Hash
+	h.SetSeed(debugSeed)
+	h.WriteString(string(m))
+	return h.Sum64()
+}
+
+// newRODebug returns the debug state for a new view m. gen is the
+// generation counter of the Buffer or Arena m views, if any.
+func newRODebug(m unsafeString, gen *uint64) roDebug {
+	return roDebug{newRODebugInfo(m, gen, true)}
+}
+
+// newRODebugGen is like newRODebug, but the returned state only
+// tracks gen, without checksumming m.
+func newRODebugGen(m unsafeString, gen *uint64) roDebug {
+	return roDebug{newRODebugInfo(m, gen, false)}
+}
+
+func newRODebugInfo(m unsafeString, gen *uint64, sum bool) *roDebugInfo {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(4, pcs) // skip Callers, newRODebugInfo, newRODebug and its caller
+	di := &roDebugInfo{orig: m, stack: pcs[:n]}
+	if gen != nil {
+		di.gen, di.wantGen = gen, *gen
+	}
+	if sum {
+		di.sums = make([]uint64, 0, (len(m)+debugBlock-1)/debugBlock)
+		for i := 0; i < len(m); i += debugBlock {
+			di.sums = append(di.sums, debugSum(debugBlockAt(m, i)))
+		}
+	}
+	return di
+}
+
+// debugBlockAt returns the block of m starting at i.
+func debugBlockAt(m unsafeString, i int) unsafeString {
+	if len(m)-i > debugBlock {
+		return m[i : i+debugBlock]
+	}
+	return m[i:]
+}
+
+// check panics if the memory viewed by r was modified since the
+// view was created.
+func (r RO) check() {
+	di := r.d.info
+	if di == nil {
+		return
+	}
+	if di.gen != nil && *di.gen != di.wantGen {
+		panic(fmt.Sprintf("mem: RO used after the mem.Buffer, Arena or MappedFile it views was Reset, Truncated or Closed; view created at:\n%s", formatStack(di.stack)))
+	}
+	if di.sums == nil || len(r.m) == 0 {
+		return
+	}
+	// Only rehash the blocks of orig that r overlaps.
+	off := int(uintptr(unsafe.Pointer((*stringHeader)(unsafe.Pointer(&r.m)).P)) -
+		uintptr(unsafe.Pointer((*stringHeader)(unsafe.Pointer(&di.orig)).P)))
+	for blk := off / debugBlock; blk*debugBlock < off+len(r.m); blk++ {
+		if debugSum(debugBlockAt(di.orig, blk*debugBlock)) != di.sums[blk] {
+			panic(fmt.Sprintf("mem: memory viewed by an RO was modified after mem.B was called; B called from:\n%s", formatStack(di.stack)))
+		}
+	}
+}
+
+func formatStack(pcs []uintptr) string {
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
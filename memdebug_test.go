@@ -0,0 +1,172 @@
+//go:build memdebug
+// +build memdebug
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestMemDebug(t *testing.T) {
+	uses := map[string]func(RO){
+		"Equal":       func(m RO) { m.Equal(S("x")) },
+		"EqualString": func(m RO) { m.EqualString("x") },
+		"MapHash":     func(m RO) { m.MapHash() },
+		"StringCopy":  func(m RO) { m.StringCopy() },
+		"ParseInt":    func(m RO) { ParseInt(m, 10, 64) },
+		"ParseFloat":  func(m RO) { ParseFloat(m, 64) },
+		"SliceFrom":   func(m RO) { m.SliceFrom(1).StringCopy() },
+	}
+	for name, use := range uses {
+		b := []byte("12345")
+		m := B(b)
+		use(m) // fine before mutation
+
+		b[4] = '0'
+		func() {
+			defer func() {
+				e := recover()
+				if e == nil {
+					t.Errorf("%s: no panic after mutation", name)
+					return
+				}
+				if msg, _ := e.(string); !strings.Contains(msg, "memdebug_test.go") {
+					t.Errorf("%s: panic doesn't mention where B was called: %v", name, e)
+				}
+			}()
+			use(m)
+		}()
+	}
+
+	// Views of strings are never checked.
+	S("12345").StringCopy()
+}
+
+func TestMemDebugFields(t *testing.T) {
+	for name, fields := range map[string]func(RO) []RO{
+		"AppendFields":        func(m RO) []RO { return AppendFields(nil, m) },
+		"AppendFieldsUnicode": func(m RO) []RO { return AppendFields(nil, m) },
+		"AppendFieldsFunc":    func(m RO) []RO { return AppendFieldsFunc(nil, m, unicode.IsSpace) },
+	} {
+		text := "12 345"
+		if name == "AppendFieldsUnicode" {
+			text = "12\u00a0345"
+		}
+		b := []byte(text)
+		f := fields(B(b))
+		last := f[len(f)-1]
+		last.StringCopy()
+
+		b[len(b)-1] = '0'
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic after mutating a field's bytes", name)
+				}
+			}()
+			last.StringCopy()
+		}()
+	}
+}
+
+func TestMemDebugSubview(t *testing.T) {
+	b := make([]byte, 3*debugBlock)
+	m := B(b)
+	sub := m.Slice(debugBlock-2, debugBlock+2) // spans two blocks
+
+	b[len(b)-1] = 1 // outside sub's blocks: only views covering it notice
+	sub.StringCopy()
+	m.SliceTo(2 * debugBlock).StringCopy()
+	for _, i := range []int{debugBlock - 1, debugBlock} {
+		b[i] = 1
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic after modifying byte %d", i)
+				}
+			}()
+			sub.StringCopy()
+		}()
+		b[i] = 0
+	}
+}
+
+func TestMemDebugMappedFile(t *testing.T) {
+	path := tempFile(t, "12345")
+	defer os.Remove(path)
+	f, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := f.RO().SliceFrom(1)
+	m.StringCopy()
+	f.Close()
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic using a view after MappedFile.Close")
+		}
+	}()
+	m.Len() // Len doesn't read the view
+	m.StringCopy()
+}
+
+func TestMemDebugBuffer(t *testing.T) {
+	for name, invalidate := range map[string]func(*Buffer){
+		"Reset":    (*Buffer).Reset,
+		"Truncate": func(b *Buffer) { b.Truncate(b.Len()) },
+	} {
+		var b Buffer
+		b.WriteString("12345")
+		m := b.ViewRange(1, 3)
+		b.WriteString("678") // appending doesn't invalidate views
+		m.StringCopy()
+
+		invalidate(&b)
+		func() {
+			defer func() {
+				e := recover()
+				if e == nil {
+					t.Errorf("%s: no panic using stale view", name)
+					return
+				}
+				if msg, _ := e.(string); !strings.Contains(msg, "memdebug_test.go") {
+					t.Errorf("%s: panic doesn't mention where the view was created: %v", name, e)
+				}
+			}()
+			m.StringCopy()
+		}()
+		b.View().StringCopy() // fresh views are fine
+	}
+}
+
+func TestMemDebugArena(t *testing.T) {
+	var a Arena
+	m := a.Get(a.Add(S("foo")))
+	m.StringCopy()
+	a.Reset()
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic using a view after Arena.Reset")
+		}
+	}()
+	m.StringCopy()
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"unsafe"
+)
+
+// MappedFile is a read-only memory-mapped file, whose contents are
+// accessed as an RO.
+//
+// On platforms without mmap support, the file's contents are read
+// into memory instead, so MappedFile is always usable.
+type MappedFile struct {
+	data   []byte
+	mapped bool // data came from mapFile, not ReadAll
+	closed bool
+	gen    uint64 // incremented by Close, to catch stale views in memdebug builds
+}
+
+// Advice is a hint to the operating system about how a MappedFile's
+// memory will be accessed. Advice is only a hint, and only Linux acts
+// on it; elsewhere it's ignored.
+type Advice int
+
+const (
+	AdviceNormal     Advice = iota // no special treatment
+	AdviceSequential               // expect sequential access; read ahead aggressively
+	AdviceRandom                   // expect random access; don't read ahead
+	AdviceWillNeed                 // expect access soon; start reading now
+)
+
+var errMappedFileClosed = errors.New("mem: MappedFile already closed")
+
+// OpenMapped opens the named file and maps its contents into memory
+// read-only.
+//
+// The file's contents must not be modified (for instance, truncated)
+// while it's mapped, as accessing the mapping may then crash the
+// program.
+//
+// Files that can't be mapped, such as pipes and devices, and files
+// whose size is reported as zero, such as those under /proc on Linux,
+// are read into memory instead.
+func OpenMapped(path string) (*MappedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if !fi.Mode().IsRegular() || size == 0 {
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return &MappedFile{data: data}, nil
+	}
+	if size != int64(int(size)) {
+		return nil, errors.New("mem: file too large to map: " + path)
+	}
+	data, err := mapFile(f, int(size))
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: path, Err: err}
+	}
+	return &MappedFile{data: data, mapped: true}, nil
+}
+
+// RO returns a view of the file's contents.
+//
+// The view, and any views derived from it, are only valid until f is
+// closed. Using them after Close may crash the program.
+func (f *MappedFile) RO() RO {
+	if len(f.data) == 0 {
+		return RO{m: ""}
+	}
+	m := *(*unsafeString)(unsafe.Pointer(&stringHeader{&f.data[0], len(f.data)}))
+	// The mapping is read-only, so memdebug builds only need to catch
+	// views used after Close, not hash the whole file.
+	return RO{d: newRODebugGen(m, &f.gen), m: m}
+}
+
+// Len returns the length of the file's contents.
+func (f *MappedFile) Len() int { return len(f.data) }
+
+// Advise tells the operating system how the file's contents will be
+// accessed.
+//
+// Advise only has an effect on Linux. Package syscall has no madvise
+// elsewhere, so on other platforms, including darwin and the BSDs
+// where the file is still mapped, it does nothing.
+func (f *MappedFile) Advise(a Advice) error {
+	if f.closed {
+		return errMappedFileClosed
+	}
+	if !f.mapped {
+		return nil
+	}
+	return madvise(f.data, a)
+}
+
+// Close unmaps the file. All views of its contents become invalid.
+func (f *MappedFile) Close() error {
+	if f.closed {
+		return errMappedFileClosed
+	}
+	f.closed = true
+	f.gen++
+	data := f.data
+	f.data = nil
+	if !f.mapped {
+		return nil
+	}
+	return unmap(data)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func tempFile(t *testing.T, contents string) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", "mem-mmap-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestMappedFile(t *testing.T) {
+	contents := strings.Repeat("some memory.\n", 1000)
+	path := tempFile(t, contents)
+	defer os.Remove(path)
+
+	f, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != len(contents) || !f.RO().EqualString(contents) {
+		t.Fatalf("mapped contents differ from file")
+	}
+	for _, a := range []Advice{AdviceSequential, AdviceRandom, AdviceWillNeed, AdviceNormal} {
+		if err := f.Advise(a); err != nil {
+			t.Errorf("Advise(%d): %v", a, err)
+		}
+	}
+	if n := CountFold(f.RO(), S("MEMORY")); n != 1000 {
+		t.Errorf("CountFold = %d; want 1000", n)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f.RO().Len() != 0 {
+		t.Errorf("RO after Close has length %d", f.RO().Len())
+	}
+	if err := f.Close(); err == nil {
+		t.Error("second Close succeeded")
+	}
+	if err := f.Advise(AdviceNormal); err == nil {
+		t.Error("Advise after Close succeeded")
+	}
+}
+
+func TestMappedFileEmpty(t *testing.T) {
+	path := tempFile(t, "")
+	defer os.Remove(path)
+	f, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.RO().Len() != 0 {
+		t.Errorf("empty file has length %d", f.RO().Len())
+	}
+	if err := f.Advise(AdviceSequential); err != nil {
+		t.Error(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMappedFileUnsized(t *testing.T) {
+	const path = "/proc/self/status"
+	if _, err := os.Stat(path); err != nil {
+		t.Skip(err)
+	}
+	f, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !HasPrefix(f.RO(), S("Name:")) {
+		t.Errorf("OpenMapped(%q) = %q; want process status", path, f.RO().StringCopy())
+	}
+	if err := f.Advise(AdviceSequential); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenMappedDir(t *testing.T) {
+	if f, err := OpenMapped(os.TempDir()); err == nil {
+		f.Close()
+		t.Error("OpenMapped(dir) succeeded")
+	}
+}
+
+func TestOpenMappedMissing(t *testing.T) {
+	if _, err := OpenMapped("/nonexistent/file"); !os.IsNotExist(err) {
+		t.Errorf("OpenMapped(missing) = %v; want not-exist error", err)
+	}
+}
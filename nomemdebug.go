@@ -0,0 +1,34 @@
+//go:build !memdebug
+// +build !memdebug
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+// memDebug reports whether the package was built with the memdebug
+// tag.
+const memDebug = false
+
+// roDebug is empty without the memdebug tag, so RO stays the size of
+// a string and its checks compile away.
+type roDebug struct{}
+
+func newRODebug(unsafeString, *uint64) roDebug { return roDebug{} }
+
+func newRODebugGen(unsafeString, *uint64) roDebug { return roDebug{} }
+
+func (r RO) check() {}
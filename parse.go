@@ -0,0 +1,660 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"math"
+	"strconv"
+)
+
+// ParseUintPrefix is like ParseUint, but parses the longest prefix of
+// m that is an unsigned integer rather than requiring all of m to be
+// one. It returns the value and the length of the prefix. If m
+// doesn't start with an integer, or the integer is out of range for
+// bitSize, ok is false. It never allocates.
+//
+// The syntax accepted, including base prefixes and underscores when
+// base is 0, is that of strconv.ParseUint.
+func ParseUintPrefix(m RO, base, bitSize int) (v uint64, n int, ok bool) {
+	m.check()
+	return parseUintPrefix(m.str(), base, bitSize)
+}
+
+// ParseIntPrefix is like ParseInt, but parses the longest prefix of m
+// that is a signed integer rather than requiring all of m to be one.
+// It returns the value and the length of the prefix. If m doesn't
+// start with an integer, or the integer is out of range for bitSize,
+// ok is false. It never allocates.
+//
+// The syntax accepted, including base prefixes and underscores when
+// base is 0, is that of strconv.ParseInt.
+func ParseIntPrefix(m RO, base, bitSize int) (v int64, n int, ok bool) {
+	m.check()
+	s := m.str()
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	} else if bitSize < 0 || bitSize > 64 {
+		return 0, 0, false
+	}
+	neg := false
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		neg = s[0] == '-'
+		n = 1
+	}
+	un, size, ok := parseUintPrefix(s[n:], base, 64)
+	if !ok {
+		return 0, 0, false
+	}
+	cutoff := uint64(1) << uint(bitSize-1)
+	if !neg && un >= cutoff || neg && un > cutoff {
+		return 0, 0, false
+	}
+	v = int64(un)
+	if neg {
+		v = -v
+	}
+	return v, n + size, true
+}
+
+// TryParseUint is like ParseUint, but reports errors without
+// allocating. The error is nil, strconv.ErrSyntax or strconv.ErrRange;
+// for the latter, the value is the maximum for bitSize, as with
+// strconv.ParseUint. An invalid base or bitSize is a syntax error.
+func TryParseUint(m RO, base, bitSize int) (uint64, error) {
+	m.check()
+	return parseUint(m.str(), base, bitSize)
+}
+
+// TryParseInt is like ParseInt, but reports errors without
+// allocating. The error is nil, strconv.ErrSyntax or strconv.ErrRange;
+// for the latter, the value is the maximum magnitude for bitSize with
+// the appropriate sign, as with strconv.ParseInt. An invalid base or
+// bitSize is a syntax error.
+func TryParseInt(m RO, base, bitSize int) (int64, error) {
+	m.check()
+
+	// Copied from the Go standard library (BSD license).
+	s := m.str()
+	if s == "" {
+		return 0, strconv.ErrSyntax
+	}
+	neg := false
+	if s[0] == '+' {
+		s = s[1:]
+	} else if s[0] == '-' {
+		neg = true
+		s = s[1:]
+	}
+	un, err := parseUint(s, base, bitSize)
+	if err != nil && err != strconv.ErrRange {
+		return 0, err
+	}
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	}
+	cutoff := uint64(1) << uint(bitSize-1)
+	if !neg && un >= cutoff {
+		return int64(cutoff - 1), strconv.ErrRange
+	}
+	if neg && un > cutoff {
+		return -int64(cutoff), strconv.ErrRange
+	}
+	n := int64(un)
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
+
+// parseUint is strconv.ParseUint, returning the sentinel errors
+// themselves rather than wrapping them in a *strconv.NumError.
+func parseUint(s string, base, bitSize int) (uint64, error) {
+	// Copied from the Go standard library (BSD license).
+	if s == "" {
+		return 0, strconv.ErrSyntax
+	}
+	base0 := base == 0
+	s0 := s
+	switch {
+	case 2 <= base && base <= 36:
+		// valid base; nothing to do
+	case base == 0:
+		// Look for octal, hex prefix.
+		base = 10
+		if s[0] == '0' {
+			switch {
+			case len(s) >= 3 && s[1]|0x20 == 'b':
+				base = 2
+				s = s[2:]
+			case len(s) >= 3 && s[1]|0x20 == 'o':
+				base = 8
+				s = s[2:]
+			case len(s) >= 3 && s[1]|0x20 == 'x':
+				base = 16
+				s = s[2:]
+			default:
+				base = 8
+				s = s[1:]
+			}
+		}
+	default:
+		return 0, strconv.ErrSyntax
+	}
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	} else if bitSize < 0 || bitSize > 64 {
+		return 0, strconv.ErrSyntax
+	}
+
+	b := uint64(base)
+	cutoff := ^uint64(0)/b + 1 // first value that overflows when multiplied by base
+	maxVal := uint64(1)<<uint(bitSize) - 1
+	underscores := false
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' && base0 {
+			underscores = true
+			continue
+		}
+		d := digitVal(c)
+		if d >= b {
+			return 0, strconv.ErrSyntax
+		}
+		if n >= cutoff {
+			// n*base overflows
+			return maxVal, strconv.ErrRange
+		}
+		n *= b
+		n1 := n + d
+		if n1 < n || n1 > maxVal {
+			// n+d overflows
+			return maxVal, strconv.ErrRange
+		}
+		n = n1
+	}
+	if underscores && !underscoreOK(s0) {
+		return 0, strconv.ErrSyntax
+	}
+	return n, nil
+}
+
+// underscoreOK reports whether the underscores in s are allowed,
+// following strconv: they may only separate digits, or a base prefix
+// and a digit.
+func underscoreOK(s string) bool {
+	// Copied from the Go standard library (BSD license).
+
+	// saw tracks the last character (class) we saw:
+	// ^ for beginning of number,
+	// 0 for a digit or base prefix,
+	// _ for an underscore,
+	// ! for none of the above.
+	saw := '^'
+	i := 0
+
+	// Optional sign.
+	if len(s) >= 1 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+
+	// Optional base prefix.
+	hex := false
+	if len(s) >= 2 && s[0] == '0' && (s[1]|0x20 == 'b' || s[1]|0x20 == 'o' || s[1]|0x20 == 'x') {
+		i = 2
+		saw = '0' // base prefix counts as a digit for "underscore as digit separator"
+		hex = s[1]|0x20 == 'x'
+	}
+
+	// Number proper.
+	for ; i < len(s); i++ {
+		// Digits are always okay.
+		if '0' <= s[i] && s[i] <= '9' || hex && 'a' <= s[i]|0x20 && s[i]|0x20 <= 'f' {
+			saw = '0'
+			continue
+		}
+		// Underscore must follow digit.
+		if s[i] == '_' {
+			if saw != '0' {
+				return false
+			}
+			saw = '_'
+			continue
+		}
+		// Underscore must also be followed by digit.
+		if saw == '_' {
+			return false
+		}
+		// Saw non-digit, non-underscore.
+		saw = '!'
+	}
+	return saw != '_'
+}
+
+// digitVal returns the value of c as a digit in bases up to 36, or 36
+// if c isn't a digit in any of them.
+func digitVal(c byte) uint64 {
+	switch {
+	case '0' <= c && c <= '9':
+		return uint64(c - '0')
+	case 'a' <= c|0x20 && c|0x20 <= 'z':
+		return uint64(c|0x20-'a') + 10
+	}
+	return 36
+}
+
+func parseUintPrefix(s string, base, bitSize int) (uint64, int, bool) {
+	// Copied from the Go standard library (BSD license).
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	} else if bitSize < 0 || bitSize > 64 {
+		return 0, 0, false
+	}
+	i := 0
+	underscores := false
+	switch {
+	case base == 0:
+		underscores = true
+		base = 10
+		if len(s) > 0 && s[0] == '0' {
+			base = 8
+			prefixBase := 0
+			if len(s) > 1 {
+				switch s[1] | 0x20 {
+				case 'b':
+					prefixBase = 2
+				case 'o':
+					prefixBase = 8
+				case 'x':
+					prefixBase = 16
+				}
+			}
+			// The base prefix only counts if a digit follows it,
+			// possibly after an underscore.
+			j := 2
+			if j < len(s) && s[j] == '_' {
+				j++
+			}
+			if prefixBase != 0 && j < len(s) && digitVal(s[j]) < uint64(prefixBase) {
+				base, i = prefixBase, j
+			}
+		}
+	case base < 2 || base > 36:
+		return 0, 0, false
+	}
+
+	b := uint64(base)
+	maxVal := uint64(1)<<uint(bitSize) - 1
+	cutoff := ^uint64(0)/b + 1
+	start := i
+	var v uint64
+	for i < len(s) {
+		c := s[i]
+		// An underscore must separate two digits.
+		if c == '_' && underscores && i > start && i+1 < len(s) && digitVal(s[i+1]) < b {
+			i++
+			continue
+		}
+		d := digitVal(c)
+		if d >= b {
+			break
+		}
+		if v >= cutoff {
+			return 0, 0, false
+		}
+		v *= b
+		v1 := v + d
+		if v1 < v || v1 > maxVal {
+			return 0, 0, false
+		}
+		v = v1
+		i++
+	}
+	if i == start {
+		return 0, 0, false
+	}
+	return v, i, true
+}
+
+// ParseFloatPrefix is like ParseFloat, but parses the longest prefix
+// of m that is a floating-point number rather than requiring all of m
+// to be one. It returns the value and the length of the prefix. If m
+// doesn't start with a number, or the number overflows bitSize, ok is
+// false. It never allocates.
+//
+// It accepts decimal numbers with an optional exponent, and the
+// infinity and NaN forms, as strconv.ParseFloat does. Hexadecimal
+// numbers and underscores are not accepted.
+func ParseFloatPrefix(m RO, bitSize int) (v float64, n int, ok bool) {
+	m.check()
+	s := m.str()
+	n, overflow := floatPrefix(s, bitSize)
+	if n == 0 || overflow {
+		return 0, 0, false
+	}
+	v, err := strconv.ParseFloat(s[:n], bitSize)
+	if err != nil {
+		// Can't happen: floatPrefix checked the syntax and range.
+		return 0, 0, false
+	}
+	return v, n, true
+}
+
+// TryParseFloat is like ParseFloat, but reports errors without
+// allocating. The error is nil, strconv.ErrSyntax or strconv.ErrRange;
+// for the latter, the value is ±Inf, as with strconv.ParseFloat.
+func TryParseFloat(m RO, bitSize int) (float64, error) {
+	m.check()
+	s := m.str()
+	n, overflow := floatPrefix(s, bitSize)
+	if n == 0 || n < len(s) {
+		var ok bool
+		if ok, overflow = otherFloat(s, bitSize); !ok {
+			return 0, strconv.ErrSyntax
+		}
+	}
+	if overflow {
+		if s[0] == '-' {
+			return math.Inf(-1), strconv.ErrRange
+		}
+		return math.Inf(1), strconv.ErrRange
+	}
+	v, _ := strconv.ParseFloat(s, bitSize) // can't fail: checked above
+	return v, nil
+}
+
+// otherFloat reports whether all of s is a number that
+// strconv.ParseFloat accepts but floatPrefix may not, as it has a
+// hexadecimal mantissa or underscores, and if so, whether its value
+// overflows bitSize. It's adapted from strconv's readFloat.
+func otherFloat(s string, bitSize int) (ok, overflow bool) {
+	// Copied from the Go standard library (BSD license).
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	hex := i+2 < len(s) && s[i] == '0' && s[i+1]|0x20 == 'x'
+	base, maxMantDigits, expChar := uint64(10), 19, byte('e')
+	if hex {
+		base, maxMantDigits, expChar = 16, 16, 'p'
+		i += 2
+	}
+
+	// The hexadecimal mantissa, as in readFloat. Decimal mantissas
+	// are passed to floatOverflows as written.
+	mantStart := i
+	var mantissa uint64
+	nd, ndMant, dp := 0, 0, 0
+	sawdot, sawdigits, trunc := false, false, false
+loop:
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '_':
+		case c == '.':
+			if sawdot {
+				break loop
+			}
+			sawdot = true
+			dp = nd
+		case '0' <= c && c <= '9' || hex && 'a' <= c|0x20 && c|0x20 <= 'f':
+			sawdigits = true
+			if c == '0' && nd == 0 { // ignore leading zeros
+				dp--
+				continue
+			}
+			nd++
+			if ndMant < maxMantDigits {
+				mantissa = mantissa*base + digitVal(c)
+				ndMant++
+			} else if c != '0' {
+				trunc = true
+			}
+		default:
+			break loop
+		}
+	}
+	if !sawdigits {
+		return false, false
+	}
+	mantEnd := i
+	if !sawdot {
+		dp = nd
+	}
+
+	exp := 0
+	if i < len(s) && s[i]|0x20 == expChar {
+		i++
+		neg := false
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			neg = s[i] == '-'
+			i++
+		}
+		if i >= len(s) || s[i] < '0' || s[i] > '9' {
+			return false, false
+		}
+		for ; i < len(s) && ('0' <= s[i] && s[i] <= '9' || s[i] == '_'); i++ {
+			if s[i] != '_' && exp < 10000 {
+				exp = exp*10 + int(s[i]-'0')
+			}
+		}
+		if neg {
+			exp = -exp
+		}
+	} else if hex {
+		return false, false // the exponent is required
+	}
+	if i < len(s) || !underscoreOK(s) {
+		return false, false
+	}
+
+	if !hex {
+		limit := float64Overflow
+		if bitSize == 32 {
+			limit = float32Overflow
+		}
+		return true, floatOverflows(s[mantStart:mantEnd], exp, limit)
+	}
+	if mantissa == 0 {
+		return true, false
+	}
+	return true, hexFloatOverflows(mantissa, 4*dp+exp-4*ndMant, trunc, bitSize)
+}
+
+// hexFloatOverflows reports whether mantissa * 2^exp, rounded to
+// bitSize with trunc recording lost nonzero bits, is too large to
+// represent. It follows strconv's atofHex.
+func hexFloatOverflows(mantissa uint64, exp int, trunc bool, bitSize int) bool {
+	// Copied from the Go standard library (BSD license).
+	mantbits, maxExp := uint(52), 1023
+	if bitSize == 32 {
+		mantbits, maxExp = 23, 127
+	}
+	exp += int(mantbits)
+
+	// Bring the mantissa to a leading 1 bit, mantbits more bits and
+	// two rounding bits, the last of them sticky. (atofHex also
+	// denormalizes tiny values here, which can't overflow.)
+	for mantissa>>(mantbits+2) == 0 {
+		mantissa <<= 1
+		exp--
+	}
+	if trunc {
+		mantissa |= 1
+	}
+	for mantissa>>(1+mantbits+2) != 0 {
+		mantissa = mantissa>>1 | mantissa&1
+		exp++
+	}
+
+	// Round to even; rounding up can carry into the exponent.
+	round := mantissa & 3
+	mantissa >>= 2
+	round |= mantissa & 1
+	exp += 2
+	if round == 3 && mantissa+1 == 1<<(1+mantbits) {
+		exp++
+	}
+	return exp > maxExp
+}
+
+// Decimal representations of the smallest values that round to
+// infinity as float64 (2^1024 - 2^970) and float32 (2^128 - 2^103).
+const (
+	float64Overflow = "179769313486231580793728971405303415079934132710037826936173778980444968292764750946649017977587207096330286416692887910946555547851940402630657488671505820681908902000708383676273854845817711531764475730270069855571366959622842914819860834936475292719074168444365510704342711559699508093042880177904174497792"
+	float32Overflow = "340282356779733661637539395458142568448"
+)
+
+// floatPrefix returns the length of the longest prefix of s that
+// ParseFloatPrefix accepts, or 0 if there is none, and whether its
+// value overflows bitSize.
+func floatPrefix(s string, bitSize int) (n int, overflow bool) {
+	i := 0
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		i++
+	}
+	if n := specialFloatLen(s[i:], i == 0); n > 0 {
+		return i + n, false
+	}
+
+	mantStart := i
+	digits := false
+	for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+		digits = true
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+			digits = true
+		}
+	}
+	if !digits {
+		return 0, false
+	}
+	mantEnd := i
+
+	exp := 0
+	if i < len(s) && s[i]|0x20 == 'e' {
+		j := i + 1
+		neg := false
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			neg = s[j] == '-'
+			j++
+		}
+		if j < len(s) && '0' <= s[j] && s[j] <= '9' {
+			for ; j < len(s) && '0' <= s[j] && s[j] <= '9'; j++ {
+				if exp < 1e8 {
+					exp = exp*10 + int(s[j]-'0')
+				}
+			}
+			if neg {
+				exp = -exp
+			}
+			i = j
+		}
+	}
+
+	limit := float64Overflow
+	if bitSize == 32 {
+		limit = float32Overflow
+	}
+	return i, floatOverflows(s[mantStart:mantEnd], exp, limit)
+}
+
+// specialFloatLen returns the length of the infinity or NaN form at
+// the start of s, ignoring case, or 0 if there isn't one. NaN can't
+// follow a sign.
+func specialFloatLen(s string, nanOK bool) int {
+	hasPrefixLower := func(prefix string) bool {
+		if len(s) < len(prefix) {
+			return false
+		}
+		for i := 0; i < len(prefix); i++ {
+			if s[i]|0x20 != prefix[i] {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case hasPrefixLower("infinity"):
+		return len("infinity")
+	case hasPrefixLower("inf"):
+		return len("inf")
+	case nanOK && hasPrefixLower("nan"):
+		return len("nan")
+	}
+	return 0
+}
+
+// floatOverflows reports whether the decimal mantissa mant (digits
+// with an optional point, and any underscores, which are ignored)
+// times 10^exp is at least limit, an integer.
+func floatOverflows(mant string, exp int, limit string) bool {
+	// Find the significant digits and the number of them before the
+	// point, so the value is 0.<digits> * 10^exp.
+	first := -1
+	intDigits, fracZeros := 0, 0
+	sawPoint := false
+	for i := 0; i < len(mant); i++ {
+		switch c := mant[i]; {
+		case c == '_':
+		case c == '.':
+			sawPoint = true
+		case first < 0 && c == '0':
+			if sawPoint {
+				fracZeros++
+			}
+		default:
+			if first < 0 {
+				first = i
+			}
+			if !sawPoint {
+				intDigits++
+			}
+		}
+	}
+	if first < 0 {
+		return false // zero
+	}
+	if intDigits > 0 {
+		exp += intDigits
+	} else {
+		exp -= fracZeros
+	}
+	if exp != len(limit) {
+		return exp > len(limit)
+	}
+	// Same magnitude as limit: compare digit by digit.
+	j := 0
+	for i := first; i < len(mant) && j < len(limit); i++ {
+		if mant[i] == '.' || mant[i] == '_' {
+			continue
+		}
+		if mant[i] != limit[j] {
+			return mant[i] > limit[j]
+		}
+		j++
+	}
+	// Equal so far. The mantissa is at least limit unless it ran out
+	// first and limit has nonzero digits left.
+	for ; j < len(limit); j++ {
+		if limit[j] != '0' {
+			return false
+		}
+	}
+	return true
+}
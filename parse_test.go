@@ -0,0 +1,259 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// longestPrefix returns the length of the longest prefix of s that
+// parse accepts, counting out-of-range values as accepted, and
+// whether that prefix's value is in range.
+func longestPrefix(s string, parse func(string) error) (n int, ok bool) {
+	for n := len(s); n > 0; n-- {
+		err := parse(s[:n])
+		if err == nil {
+			return n, true
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return n, false
+		}
+	}
+	return 0, false
+}
+
+var intPrefixTests = []string{
+	"", "-", "+", "_", "0", "00", "09", "0x", "0x1g", "0X_fF", "0x__1", "0b102", "0o78", "0_7",
+	"123ms", "42/tcp", "-17 apples", "+5", "1_000_000", "1__0", "1_", "_1",
+	"9223372036854775807", "9223372036854775808", "-9223372036854775808", "-9223372036854775809",
+	"18446744073709551615x", "18446744073709551616", "99999999999999999999999",
+	"127", "128", "-128", "-129", "255", "256", "zz", "Zz9",
+}
+
+func TestParseIntPrefix(t *testing.T) {
+	for _, s := range intPrefixTests {
+		for _, base := range []int{0, 2, 8, 10, 16, 36} {
+			for _, bitSize := range []int{0, 8, 64} {
+				var want int64
+				wantN, wantOK := longestPrefix(s, func(s string) (err error) {
+					want, err = strconv.ParseInt(s, base, bitSize)
+					return err
+				})
+				if !wantOK {
+					want, wantN = 0, 0
+				}
+				v, n, ok := ParseIntPrefix(S(s), base, bitSize)
+				if v != want || n != wantN || ok != wantOK {
+					t.Errorf("ParseIntPrefix(%q, %d, %d) = %d, %d, %v; want %d, %d, %v", s, base, bitSize, v, n, ok, want, wantN, wantOK)
+				}
+
+				var wantU uint64
+				wantN, wantOK = longestPrefix(s, func(s string) (err error) {
+					wantU, err = strconv.ParseUint(s, base, bitSize)
+					return err
+				})
+				if !wantOK {
+					wantU, wantN = 0, 0
+				}
+				u, n, ok := ParseUintPrefix(S(s), base, bitSize)
+				if u != wantU || n != wantN || ok != wantOK {
+					t.Errorf("ParseUintPrefix(%q, %d, %d) = %d, %d, %v; want %d, %d, %v", s, base, bitSize, u, n, ok, wantU, wantN, wantOK)
+				}
+			}
+		}
+	}
+}
+
+func TestParseIntPrefixBadArgs(t *testing.T) {
+	if _, _, ok := ParseIntPrefix(S("1"), 1, 64); ok {
+		t.Error("base 1 accepted")
+	}
+	if _, _, ok := ParseUintPrefix(S("1"), 37, 64); ok {
+		t.Error("base 37 accepted")
+	}
+	if _, _, ok := ParseIntPrefix(S("1"), 10, 65); ok {
+		t.Error("bitSize 65 accepted")
+	}
+}
+
+var floatPrefixTests = []string{
+	"", "-", ".", "+.", "e5", "1", "1.", ".5", "-.5e", "1e", "1e+", "1e+5x", "1E-5", "5.e3",
+	"123ms", "3.14159/s", "007", "0.000", "1e-400", "-1e-400", "1e400", "-1e400", "0e999999999999",
+	"inf", "-Inf", "+INFINITY", "infinit", "infinityx", "nan", "NaNa", "-nan", "+nan", "in",
+	"1_000", "0x1p3", "1.7976931348623157e308", "1.7976931348623158e308", "1.797693134862315807e308",
+	"1.7976931348623158079372897140530341507993413271003782693617377898044496829276475094664901797758720709633028641669288791094655554785194040263065748867150582068190890200070838367627385484581771153176447573027006985557136695962284291481986083493647529271907416844436551070434271155969950809304288017790417449779e308",
+	"17976931348623158079372897140530341507993413271003782693617377898044496829276475094664901797758720709633028641669288791094655554785194040263065748867150582068190890200070838367627385484581771153176447573027006985557136695962284291481986083493647529271907416844436551070434271155969950809304288017790417449779.2",
+	"179769313486231580793728971405303415079934132710037826936173778980444968292764750946649017977587207096330286416692887910946555547851940402630657488671505820681908902000708383676273854845817711531764475730270069855571366959622842914819860834936475292719074168444365510704342711559699508093042880177904174497792.0",
+	"0.0000179769313486231580793728971405303415079934132710037826936173778980444968292764750946649017977587207096330286416692887910946555547851940402630657488671505820681908902000708383676273854845817711531764475730270069855571366959622842914819860834936475292719074168444365510704342711559699508093042880177904174497791e313",
+	"3.4028235e38", "3.4028236e38", "340282356779733661637539395458142568447", "340282356779733661637539395458142568448",
+	strings.Repeat("0", 500) + "1.5x",
+
+	// Hexadecimal and underscore forms, which only TryParseFloat accepts.
+	"0x1p3", "-0X1.8P-2", "+0x.8p1", "0x1e3p0", "0x1p", "0x1", "0xp1", "0x.p1", "0x1p+", "0x1p3x",
+	"0x1p1023", "0x1p1024", "-0x1p1024", "0x1.fffffffffffffp1023", "0x1.fffffffffffff7p1023",
+	"0x1.fffffffffffff8p1023", "0x1.fffffffffffff0000000001p1023", "0x1.ffffffp127", "0x1.fffffefp127",
+	"0x1p-1080", "0x0p99999", "0x_1p0", "0x1_p0", "0x1_0.8_0p1_0", "0x1p1__0",
+	"1_000", "1_000.000_1", "1__0", "_1", "1_", "1_.5", "1._5", "1e1_0", "1e_1", "1_0e307", "1_0e308", "-1_7976931348623159e292",
+}
+
+func TestParseFloatPrefix(t *testing.T) {
+	for _, s := range floatPrefixTests {
+		for _, bitSize := range []int{32, 64} {
+			var want float64
+			wantN, wantOK := longestPrefix(s, func(s string) (err error) {
+				if strings.ContainsAny(s, "_xX") {
+					return strconv.ErrSyntax // not supported by ParseFloatPrefix
+				}
+				want, err = strconv.ParseFloat(s, bitSize)
+				return err
+			})
+			if !wantOK {
+				want, wantN = 0, 0
+			}
+			v, n, ok := ParseFloatPrefix(S(s), bitSize)
+			if !(v == want || math.IsNaN(v) && math.IsNaN(want)) || n != wantN || ok != wantOK {
+				t.Errorf("ParseFloatPrefix(%q, %d) = %v, %d, %v; want %v, %d, %v", s, bitSize, v, n, ok, want, wantN, wantOK)
+			}
+		}
+	}
+}
+
+func TestFloatOverflowConstants(t *testing.T) {
+	for _, tt := range []struct {
+		got           string
+		mant, lowBits uint
+	}{
+		{float64Overflow, 1024, 970},
+		{float32Overflow, 128, 103},
+	} {
+		want := new(big.Int).Lsh(big.NewInt(1), tt.mant)
+		want.Sub(want, new(big.Int).Lsh(big.NewInt(1), tt.lowBits))
+		if tt.got != want.String() {
+			t.Errorf("overflow constant for 2^%d = %s; want %s", tt.mant, tt.got, want)
+		}
+	}
+}
+
+func TestParsePrefixAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	inputs := [][]byte{[]byte("123ms"), []byte("x"), []byte("99999999999999999999"), []byte("1e999"), []byte("2.5s")}
+	n := testing.AllocsPerRun(100, func() {
+		for _, in := range inputs {
+			ParseIntPrefix(B(in), 0, 64)
+			ParseUintPrefix(B(in), 10, 32)
+			ParseFloatPrefix(B(in), 64)
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v; want 0", n)
+	}
+}
+
+// numErrorKind returns the sentinel underlying an error from strconv.
+func numErrorKind(err error) error {
+	if ne, ok := err.(*strconv.NumError); ok {
+		return ne.Err
+	}
+	return err
+}
+
+func TestTryParse(t *testing.T) {
+	// strconv reports overflow as soon as it sees it, before checking
+	// the rest of the syntax.
+	quirks := []string{"1__99999999999999999999", "0x__ffffffffffffffffffff", "99999999999999999999x", "0x_", "0_"}
+	tests := append(append(quirks, intPrefixTests...), floatPrefixTests...)
+	for _, s := range tests {
+		for _, base := range []int{0, 2, 10, 16, 36} {
+			for _, bitSize := range []int{0, 8, 64} {
+				want, wantErr := strconv.ParseInt(s, base, bitSize)
+				got, err := TryParseInt(S(s), base, bitSize)
+				if got != want || err != numErrorKind(wantErr) {
+					t.Errorf("TryParseInt(%q, %d, %d) = %d, %v; want %d, %v", s, base, bitSize, got, err, want, wantErr)
+				}
+
+				wantU, wantErr := strconv.ParseUint(s, base, bitSize)
+				gotU, err := TryParseUint(S(s), base, bitSize)
+				if gotU != wantU || err != numErrorKind(wantErr) {
+					t.Errorf("TryParseUint(%q, %d, %d) = %d, %v; want %d, %v", s, base, bitSize, gotU, err, wantU, wantErr)
+				}
+			}
+		}
+		for _, bitSize := range []int{32, 64} {
+			want, wantErr := strconv.ParseFloat(s, bitSize)
+			got, err := TryParseFloat(S(s), bitSize)
+			if !(got == want || math.IsNaN(got) && math.IsNaN(want)) || err != numErrorKind(wantErr) {
+				t.Errorf("TryParseFloat(%q, %d) = %v, %v; want %v, %v", s, bitSize, got, err, want, wantErr)
+			}
+		}
+	}
+}
+
+func TestTryParseAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	inputs := [][]byte{[]byte("123"), []byte("12x"), []byte(""), []byte("99999999999999999999"), []byte("1e999"), []byte("-2.5"),
+		[]byte("0x1p3"), []byte("0x1p"), []byte("0x1p1024"), []byte("1_000"), []byte("1__0"), []byte("1_0e999")}
+	n := testing.AllocsPerRun(100, func() {
+		for _, in := range inputs {
+			TryParseInt(B(in), 0, 64)
+			TryParseUint(B(in), 10, 32)
+			TryParseFloat(B(in), 64)
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v; want 0", n)
+	}
+}
+
+func TestParseErrorOwnsInput(t *testing.T) {
+	parsers := map[string]func(RO) error{
+		"ParseInt":   func(m RO) error { _, err := ParseInt(m, 10, 64); return err },
+		"ParseUint":  func(m RO) error { _, err := ParseUint(m, 10, 64); return err },
+		"ParseFloat": func(m RO) error { _, err := ParseFloat(m, 64); return err },
+		"ParseBool":  func(m RO) error { _, err := ParseBool(m); return err },
+	}
+	for name, parse := range parsers {
+		b := []byte("12x4")
+		err := parse(B(b))
+		if err == nil {
+			t.Fatalf("%s: no error", name)
+		}
+		before := err.Error()
+		copy(b, "zzzz")
+		if after := err.Error(); after != before {
+			t.Errorf("%s: error changed after input was modified: %q -> %q", name, before, after)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	for _, s := range []string{"", "1", "t", "T", "TRUE", "true", "True", "0", "f", "FALSE", "tRUE", "yes"} {
+		want, wantErr := strconv.ParseBool(s)
+		got, err := ParseBool(S(s))
+		if got != want || (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+			t.Errorf("ParseBool(%q) = %v, %v; want %v, %v", s, got, err, want, wantErr)
+		}
+	}
+}
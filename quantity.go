@@ -0,0 +1,278 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"math"
+	"math/bits"
+	"strconv"
+	"time"
+)
+
+// ParseDuration is like time.ParseDuration, but parses m and reports
+// errors without allocating. The error is nil, strconv.ErrSyntax for
+// a malformed duration or unknown unit, or strconv.ErrRange if the
+// duration doesn't fit in a time.Duration.
+func ParseDuration(m RO) (time.Duration, error) {
+	m.check()
+
+	// Copied from the Go standard library (BSD license).
+	// [-+]?([0-9]*(\.[0-9]*)?[a-z]+)+
+	s := m.str()
+	var d uint64
+	neg := false
+
+	// Consume [-+]?
+	if s != "" {
+		c := s[0]
+		if c == '-' || c == '+' {
+			neg = c == '-'
+			s = s[1:]
+		}
+	}
+	// Special case: if all that is left is "0", this is zero.
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, strconv.ErrSyntax
+	}
+	for s != "" {
+		var (
+			v, f  uint64      // integers before, after decimal point
+			scale float64 = 1 // value = v + f/scale
+		)
+
+		// The next character must be [0-9.]
+		if !(s[0] == '.' || '0' <= s[0] && s[0] <= '9') {
+			return 0, strconv.ErrSyntax
+		}
+		// Consume [0-9]*
+		v, n, ok := parseUintPrefix(s, 10, 64)
+		if !ok && s[0] != '.' || v > 1<<63 {
+			// overflow
+			return 0, strconv.ErrRange
+		}
+		pre := n > 0 // whether we consumed anything before a period
+		s = s[n:]
+
+		// Consume (\.[0-9]*)?
+		post := false
+		if s != "" && s[0] == '.' {
+			s = s[1:]
+			pl := len(s)
+			f, scale, s = leadingFraction(s)
+			post = pl != len(s)
+		}
+		if !pre && !post {
+			// no digits (e.g. ".s" or "-.s")
+			return 0, strconv.ErrSyntax
+		}
+
+		// Consume unit.
+		i := 0
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '.' || '0' <= c && c <= '9' {
+				break
+			}
+		}
+		unit := durationUnit(s[:i])
+		if unit == 0 {
+			return 0, strconv.ErrSyntax
+		}
+		s = s[i:]
+		if v > 1<<63/unit {
+			return 0, strconv.ErrRange
+		}
+		v *= unit
+		if f > 0 {
+			// float64 is needed to be nanosecond accurate for fractions of hours.
+			// v >= 0 && (f*unit/scale) <= 3.6e+12 (ns/h, h is the largest unit)
+			v += uint64(float64(f) * (float64(unit) / scale))
+			if v > 1<<63 {
+				return 0, strconv.ErrRange
+			}
+		}
+		d += v
+		if d > 1<<63 {
+			return 0, strconv.ErrRange
+		}
+	}
+	if neg {
+		return -time.Duration(d), nil
+	}
+	if d > 1<<63-1 {
+		return 0, strconv.ErrRange
+	}
+	return time.Duration(d), nil
+}
+
+// durationUnit returns the length of the time.ParseDuration unit u in
+// nanoseconds, or 0 if it isn't one.
+func durationUnit(u string) uint64 {
+	switch u {
+	case "ns":
+		return uint64(time.Nanosecond)
+	case "us", "µs", "μs": // U+00B5 micro sign, U+03BC Greek letter mu
+		return uint64(time.Microsecond)
+	case "ms":
+		return uint64(time.Millisecond)
+	case "s":
+		return uint64(time.Second)
+	case "m":
+		return uint64(time.Minute)
+	case "h":
+		return uint64(time.Hour)
+	}
+	return 0
+}
+
+// leadingFraction consumes the leading [0-9]* from s, as in package
+// time. It is used only for fractions, so does not return an error on
+// overflow, it just stops accumulating precision.
+func leadingFraction(s string) (x uint64, scale float64, rem string) {
+	// Copied from the Go standard library (BSD license).
+	i := 0
+	scale = 1
+	overflow := false
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			break
+		}
+		if overflow {
+			continue
+		}
+		if x > (1<<63-1)/10 {
+			// It's possible for overflow to give a positive number, so take care.
+			overflow = true
+			continue
+		}
+		y := x*10 + uint64(c) - '0'
+		if y > 1<<63 {
+			overflow = true
+			continue
+		}
+		x = y
+		scale *= 10
+	}
+	return x, scale, s[i:]
+}
+
+// ParseByteSize parses a number of bytes with an optional unit suffix,
+// such as "512", "512B", "1.5GB" or "64 KiB". SI units (kB, MB, GB,
+// TB, PB, EB) are powers of 1000 and IEC units (KiB, MiB, GiB, TiB,
+// PiB, EiB) powers of 1024. Unit letters are matched without regard to
+// case and the trailing B may be omitted, so "1k", "1K" and "1kb" all
+// mean 1000 bytes. A single space may separate the number and the
+// unit.
+//
+// The number is decimal digits with an optional fraction; fractional
+// results are rounded to the nearest byte. The error is nil,
+// strconv.ErrSyntax, or strconv.ErrRange if the size doesn't fit in a
+// uint64. It never allocates.
+func ParseByteSize(m RO) (uint64, error) {
+	m.check()
+	s := m.str()
+	i := 0
+	for i < len(s) && ('0' <= s[i] && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	num, unit := m.SliceTo(i), s[i:]
+	if len(unit) > 1 && unit[0] == ' ' {
+		unit = unit[1:]
+	}
+	mult, ok := byteSizeUnit(unit)
+	if !ok || num.Len() == 0 {
+		return 0, strconv.ErrSyntax
+	}
+
+	if IndexByte(num, '.') < 0 {
+		v, err := TryParseUint(num, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		hi, lo := bits.Mul64(v, mult)
+		if hi != 0 {
+			return 0, strconv.ErrRange
+		}
+		return lo, nil
+	}
+	f, err := TryParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	f = math.Round(f * float64(mult))
+	if f >= 1<<64 {
+		return 0, strconv.ErrRange
+	}
+	return uint64(f), nil
+}
+
+// byteSizeUnit returns the multiplier for a ParseByteSize unit.
+func byteSizeUnit(u string) (mult uint64, ok bool) {
+	if u == "" {
+		return 1, true
+	}
+	// Strip the optional trailing B, except from a bare "B".
+	if n := len(u); u[n-1]|0x20 == 'b' {
+		if n == 1 {
+			return 1, true
+		}
+		u = u[:n-1]
+	}
+	base := uint64(1000)
+	switch len(u) {
+	case 1:
+	case 2:
+		if u[1]|0x20 != 'i' {
+			return 0, false
+		}
+		base = 1024
+	default:
+		return 0, false
+	}
+	mult = 1
+	for _, p := range "kmgtpe" {
+		mult *= base
+		if rune(u[0]|0x20) == p {
+			return mult, true
+		}
+	}
+	return 0, false
+}
+
+// ParsePercent parses a percentage such as "75%" or "-2.5%", returning
+// it as a fraction: 0.75 or -0.025. The number before the % sign is
+// parsed by TryParseFloat; infinities and NaN are rejected. The error
+// is nil, strconv.ErrSyntax or strconv.ErrRange. It never allocates.
+func ParsePercent(m RO) (float64, error) {
+	m.check()
+	n := m.Len()
+	if n == 0 || m.At(n-1) != '%' {
+		return 0, strconv.ErrSyntax
+	}
+	v, err := TryParseFloat(m.SliceTo(n-1), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, strconv.ErrSyntax
+	}
+	return v / 100, nil
+}
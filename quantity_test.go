@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+var durationTests = []string{
+	"", "0", "+0", "-0", "5s", "30s", "1478s", "-5s", "+5s", "-0s", "5.0s", "5.6s", "5.s", ".5s",
+	"1.0s", "1.00s", "1.004s", "1.0040s", "100.00100s", "10ns", "11us", "12µs", "12μs", "13ms",
+	"14s", "15m", "16h", "3h30m", "10.5s4m", "-2m3.4s", "1h2m3s4ms5us6ns", "39h9m14.425s",
+	"52763797000ns", "0.3333333333333333333h", "9007199254740993ns", "9223372036854775807ns",
+	"9223372036854775.807us", "9223372036854s775ms807us", "-9223372036854775808ns",
+	"-9223372036854775.808us", "-9223372036854s775ms808us", "-2562047h47m16.854775808s",
+	"0.100000000000000000000h", "0.830103483285477580700h", "9223372036854775808ns",
+	"9223372036854775807.5ns", "2562047h47m16.854775808s", "9999999999999999999999h",
+	"3", "-", "s", ".", "-.", ".s", "+.s", "1d", "\x85\x85", "\xffff", "hello \xffff world",
+	"1.5x", "1sm", "18446744073709551616s", "0.0000000000000000000000000000000001h",
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, s := range durationTests {
+		want, wantErr := time.ParseDuration(s)
+		got, err := ParseDuration(S(s))
+		if got != want || (err == nil) != (wantErr == nil) {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v, %v", s, got, err, want, wantErr)
+		}
+		if err != nil && err != strconv.ErrSyntax && err != strconv.ErrRange {
+			t.Errorf("ParseDuration(%q) error = %v; want a strconv sentinel", s, err)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		s    string
+		want uint64
+		err  error
+	}{
+		{"0", 0, nil},
+		{"512", 512, nil},
+		{"512B", 512, nil},
+		{"512b", 512, nil},
+		{"1k", 1000, nil},
+		{"1K", 1000, nil},
+		{"1kB", 1000, nil},
+		{"1KB", 1000, nil},
+		{"1kb", 1000, nil},
+		{"1KiB", 1024, nil},
+		{"1Ki", 1024, nil},
+		{"64 KiB", 64 << 10, nil},
+		{"512MiB", 512 << 20, nil},
+		{"1.5GB", 1500000000, nil},
+		{"1.5GiB", 3 << 29, nil},
+		{"2TB", 2e12, nil},
+		{"3PiB", 3 << 50, nil},
+		{"15EiB", 15 << 60, nil},
+		{"18EB", 18e18, nil},
+		{"0.5B", 1, nil}, // rounds half away from zero
+		{"1.001KB", 1001, nil},
+		{"18446744073709551615", 1<<64 - 1, nil},
+		{"18446744073709551616", 0, strconv.ErrRange},
+		{"16EiB", 0, strconv.ErrRange},
+		{"19EB", 0, strconv.ErrRange},
+		{"15.9999999999999999EiB", 0, strconv.ErrRange}, // rounds to 16EiB
+		{"99999999999999999999999KB", 0, strconv.ErrRange},
+		{"", 0, strconv.ErrSyntax},
+		{"B", 0, strconv.ErrSyntax},
+		{"KiB", 0, strconv.ErrSyntax},
+		{".", 0, strconv.ErrSyntax},
+		{"1..5", 0, strconv.ErrSyntax},
+		{"-1KB", 0, strconv.ErrSyntax},
+		{"+1", 0, strconv.ErrSyntax},
+		{"1  KB", 0, strconv.ErrSyntax},
+		{"1 ", 0, strconv.ErrSyntax},
+		{"1XB", 0, strconv.ErrSyntax},
+		{"1KiBB", 0, strconv.ErrSyntax},
+		{"1iB", 0, strconv.ErrSyntax},
+		{"1e3", 0, strconv.ErrSyntax},
+		{"0x10", 0, strconv.ErrSyntax},
+		{"1_000", 0, strconv.ErrSyntax},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(S(tt.s))
+		if got != tt.want || err != tt.err {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d, %v", tt.s, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+		err  error
+	}{
+		{"75%", 0.75, nil},
+		{"0%", 0, nil},
+		{"100%", 1, nil},
+		{"-2.5%", -0.025, nil},
+		{"150%", 1.5, nil},
+		{"1e2%", 1, nil},
+		{"75", 0, strconv.ErrSyntax},
+		{"%", 0, strconv.ErrSyntax},
+		{"", 0, strconv.ErrSyntax},
+		{"75 %", 0, strconv.ErrSyntax},
+		{"75%%", 0, strconv.ErrSyntax},
+		{"Inf%", 0, strconv.ErrSyntax},
+		{"NaN%", 0, strconv.ErrSyntax},
+		{"1e400%", 0, strconv.ErrRange},
+	}
+	for _, tt := range tests {
+		got, err := ParsePercent(S(tt.s))
+		if got != tt.want || err != tt.err {
+			t.Errorf("ParsePercent(%q) = %v, %v; want %v, %v", tt.s, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestQuantityAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	inputs := [][]byte{[]byte("1h30m"), []byte("1.5GB"), []byte("75%"), []byte("bogus"), []byte("99999999999h")}
+	n := testing.AllocsPerRun(100, func() {
+		for _, in := range inputs {
+			ParseDuration(B(in))
+			ParseByteSize(B(in))
+			ParsePercent(B(in))
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v; want 0", n)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// AppendQuote is like strconv.AppendQuote, but quotes m.
+func AppendQuote(dst []byte, m RO) []byte {
+	m.check()
+	return strconv.AppendQuote(dst, m.str())
+}
+
+// AppendQuoteASCII is like strconv.AppendQuoteToASCII, but quotes m.
+func AppendQuoteASCII(dst []byte, m RO) []byte {
+	m.check()
+	return strconv.AppendQuoteToASCII(dst, m.str())
+}
+
+// AppendQuoteToGraphic is like strconv.AppendQuoteToGraphic, but
+// quotes m.
+func AppendQuoteToGraphic(dst []byte, m RO) []byte {
+	m.check()
+	return strconv.AppendQuoteToGraphic(dst, m.str())
+}
+
+// AppendUnquote is like strconv.Unquote, but appends the value of the
+// single-quoted, double-quoted or backquoted Go string literal m to
+// dst instead of returning a string. If m isn't a valid literal, it
+// returns dst unchanged and strconv.ErrSyntax.
+func AppendUnquote(dst []byte, m RO) ([]byte, error) {
+	m.check()
+	out, n, err := unquote(dst, m.str(), true)
+	if err != nil {
+		return dst, err
+	}
+	if n != m.Len() {
+		return dst, strconv.ErrSyntax
+	}
+	return out, nil
+}
+
+// QuotedPrefix is like strconv.QuotedPrefix, returning the quoted
+// string, as understood by AppendUnquote, at the start of m. If m
+// doesn't start with a valid quoted string, it returns
+// strconv.ErrSyntax.
+func QuotedPrefix(m RO) (RO, error) {
+	m.check()
+	_, n, err := unquote(nil, m.str(), false)
+	if err != nil {
+		return RO{}, err
+	}
+	return m.SliceTo(n), nil
+}
+
+// unquote is strconv's unquote, appending to dst instead of
+// returning a string. It parses the quoted string at the start of in,
+// returning its length n and, if unescape is set, dst with its value
+// appended.
+func unquote(dst []byte, in string, unescape bool) (out []byte, n int, err error) {
+	// Copied from the Go standard library (BSD license).
+
+	// Determine the quote form and optimistically find the terminating quote.
+	if len(in) < 2 {
+		return dst, 0, strconv.ErrSyntax
+	}
+	quote := in[0]
+	end := strings.IndexByte(in[1:], quote)
+	if end < 0 {
+		return dst, 0, strconv.ErrSyntax
+	}
+	end += 2 // position after terminating quote; may be wrong if escape sequences are present
+
+	switch quote {
+	case '`':
+		if unescape {
+			// Carriage return characters ('\r') inside raw string
+			// literals are discarded from the raw string value.
+			for i := 1; i < end-1; i++ {
+				if in[i] != '\r' {
+					dst = append(dst, in[i])
+				}
+			}
+		}
+		return dst, end, nil
+	case '"', '\'':
+		// Handle quoted strings without any escape sequences.
+		if strings.IndexByte(in[:end], '\\') < 0 && strings.IndexByte(in[:end], '\n') < 0 {
+			var valid bool
+			switch quote {
+			case '"':
+				valid = utf8.ValidString(in[1 : end-1])
+			case '\'':
+				r, size := utf8.DecodeRuneInString(in[1 : end-1])
+				valid = 1+size+1 == end && (r != utf8.RuneError || size != 1)
+			}
+			if valid {
+				if unescape {
+					dst = append(dst, in[1:end-1]...)
+				}
+				return dst, end, nil
+			}
+		}
+
+		// Handle quoted strings with escape sequences.
+		start := len(dst)
+		in0 := in
+		in = in[1:] // skip starting quote
+		for len(in) > 0 && in[0] != quote {
+			// Process the next character,
+			// rejecting any unescaped newline characters which are invalid.
+			r, multibyte, rem, err := strconv.UnquoteChar(in, quote)
+			if in[0] == '\n' || err != nil {
+				return dst[:start], 0, strconv.ErrSyntax
+			}
+			in = rem
+
+			// Append the character if unescaping the input.
+			if unescape {
+				if r < utf8.RuneSelf || !multibyte {
+					dst = append(dst, byte(r))
+				} else {
+					dst = appendRune(dst, r)
+				}
+			}
+
+			// Single quoted strings must be a single character.
+			if quote == '\'' {
+				break
+			}
+		}
+
+		// Verify that the string ends with a terminating quote.
+		if !(len(in) > 0 && in[0] == quote) {
+			return dst[:start], 0, strconv.ErrSyntax
+		}
+		return dst, len(in0) - len(in) + 1, nil
+	default:
+		return dst, 0, strconv.ErrSyntax
+	}
+}
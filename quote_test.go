@@ -0,0 +1,96 @@
+//go:build go1.17
+// +build go1.17
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strconv"
+	"testing"
+)
+
+var unquoteTests = []string{
+	``, `"`, `'`, "`", `""`, `''`, "``", `"abc"`, `'a'`, `'ab'`, `'\n'`, `'\''`, `'"'`, `"'"`,
+	`"\""`, `"a\"b"`, `"\x41☺\U0001F600\101\t"`, `"\q"`, `"\xZZ"`, `"☺"`, `'☺'`,
+	"\"\xff\"", "'\xff'", `'\xff'`, `"\xff"`, "\"a\nb\"", "`a\nb`", "`a\r\nb`", "`a`b`",
+	`"abc"def`, `"abc" "def"`, `'a'b`, `"unterminated`, `"a\"`, `"\\"`, `x"a"`, `"\400"`, `'\400'`,
+	`"\ud800"`, `'\U00110000'`,
+}
+
+func TestAppendUnquote(t *testing.T) {
+	for _, s := range unquoteTests {
+		want, wantErr := strconv.Unquote(s)
+		got, err := AppendUnquote([]byte("prefix:"), S(s))
+		if err != wantErr {
+			t.Errorf("AppendUnquote(%q) error = %v; want %v", s, err, wantErr)
+		}
+		if wantErr != nil {
+			want = ""
+		}
+		if string(got) != "prefix:"+want {
+			t.Errorf("AppendUnquote(%q) = %q; want %q", s, got, "prefix:"+want)
+		}
+	}
+}
+
+func TestQuotedPrefix(t *testing.T) {
+	for _, s := range unquoteTests {
+		want, wantErr := strconv.QuotedPrefix(s)
+		got, err := QuotedPrefix(S(s))
+		if err != wantErr || !got.EqualString(want) {
+			t.Errorf("QuotedPrefix(%q) = %q, %v; want %q, %v", s, got.StringCopy(), err, want, wantErr)
+		}
+	}
+}
+
+func TestUnquoteAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	in := []byte(`"hello\tworld ☺" trailing`)
+	buf := make([]byte, 0, 64)
+	n := testing.AllocsPerRun(100, func() {
+		q, err := QuotedPrefix(B(in))
+		if err != nil {
+			panic(err)
+		}
+		if _, err := AppendUnquote(buf[:0], q); err != nil {
+			panic(err)
+		}
+		if _, err := AppendUnquote(buf[:0], B(in)); err == nil {
+			panic("no error")
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v; want 0", n)
+	}
+}
+
+func TestAppendQuote(t *testing.T) {
+	for _, s := range []string{"", "abc", "a\"b", "☺\n", "\xff", " x "} {
+		if got, want := string(AppendQuote([]byte("x"), S(s))), strconv.AppendQuote([]byte("x"), s); got != string(want) {
+			t.Errorf("AppendQuote(%q) = %s; want %s", s, got, want)
+		}
+		if got, want := string(AppendQuoteASCII(nil, S(s))), strconv.QuoteToASCII(s); got != want {
+			t.Errorf("AppendQuoteASCII(%q) = %s; want %s", s, got, want)
+		}
+		if got, want := string(AppendQuoteToGraphic(nil, S(s))), strconv.QuoteToGraphic(s); got != want {
+			t.Errorf("AppendQuoteToGraphic(%q) = %s; want %s", s, got, want)
+		}
+	}
+}
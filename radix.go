@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"runtime"
+	"sync"
+)
+
+// radixCutoff is the bucket size below which radix sorting falls back
+// to insertion sort.
+const radixCutoff = 32
+
+// radixParallelMin is the input size below which ParallelRadixSort
+// doesn't bother with goroutines.
+const radixParallelMin = 1 << 14
+
+// RadixSort sorts s in increasing byte order, as defined by Compare,
+// using an in-place MSD radix sort. It avoids re-comparing the common
+// prefixes that comparison sorts spend most of their time on, which
+// makes it faster for large slices of keys like file paths or
+// hostnames.
+//
+// The sort is not stable, but as equal views are indistinguishable
+// by their contents, the result is the same as SortROs.
+func RadixSort(s []RO) { radixSort(s, 0) }
+
+// ParallelRadixSort is like RadixSort, but sorts the top-level
+// buckets concurrently, using up to GOMAXPROCS goroutines. Top-level
+// buckets are formed at the first byte where the elements of s
+// differ.
+func ParallelRadixSort(s []RO) {
+	if len(s) < radixParallelMin {
+		radixSort(s, 0)
+		return
+	}
+	// Skip any prefix common to all of s, so there's more than one
+	// bucket to share out.
+	var count [257]int
+	depth := 0
+	for !radixPartition(s, depth, &count) {
+		if radixKey(s[0], depth) == 0 {
+			return // all equal
+		}
+		depth++
+	}
+
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	off := count[0] // bucket 0 holds empty strings; already sorted
+	for _, n := range count[1:] {
+		if n > 1 {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(b []RO) {
+				defer wg.Done()
+				radixSort(b, depth+1)
+				<-sem
+			}(s[off : off+n])
+		}
+		off += n
+	}
+	wg.Wait()
+}
+
+// radixKey returns the radix bucket for m at depth: 0 if m ends
+// before depth, else 1 + the byte at depth.
+func radixKey(m RO, depth int) int {
+	if depth < len(m.m) {
+		return int(m.m[depth]) + 1
+	}
+	return 0
+}
+
+// radixSort sorts s, all of whose elements share their first depth
+// bytes.
+func radixSort(s []RO, depth int) {
+	var count [257]int
+	for len(s) > radixCutoff {
+		if !radixPartition(s, depth, &count) {
+			// All of s is in one bucket.
+			if radixKey(s[0], depth) == 0 {
+				return // all equal
+			}
+			depth++
+			continue
+		}
+		off := count[0]
+		for _, n := range count[1:] {
+			if n > 1 {
+				radixSort(s[off:off+n], depth+1)
+			}
+			off += n
+		}
+		return
+	}
+	insertionSortFrom(s, depth)
+}
+
+// radixPartition permutes s in place so that its elements are grouped
+// by their radixKey at depth, in increasing order, and fills count
+// with the bucket sizes. It reports whether it had anything to do:
+// false means all of s is in a single bucket.
+func radixPartition(s []RO, depth int, count *[257]int) bool {
+	*count = [257]int{}
+	for _, m := range s {
+		count[radixKey(m, depth)]++
+	}
+	var next [257]int
+	sum := 0
+	for b, n := range count {
+		if n == len(s) {
+			return false
+		}
+		next[b] = sum
+		sum += n
+	}
+	// American flag sort: cycle each element to its bucket.
+	end := 0
+	for b, n := range count {
+		end += n
+		for next[b] < end {
+			v := s[next[b]]
+			for k := radixKey(v, depth); k != b; k = radixKey(v, depth) {
+				v, s[next[k]] = s[next[k]], v
+				next[k]++
+			}
+			s[next[b]] = v
+			next[b]++
+		}
+	}
+	return true
+}
+
+// insertionSortFrom sorts s, all of whose elements share their first
+// depth bytes, by comparing only the bytes after depth.
+func insertionSortFrom(s []RO, depth int) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j].m[depth:] < s[j-1].m[depth:]; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
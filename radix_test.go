@@ -0,0 +1,127 @@
+//go:build go1.23
+// +build go1.23
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// testPaths returns n pseudo-random file paths, with the long shared
+// prefixes typical of real ones.
+func testPaths(n int) []RO {
+	r := rand.New(rand.NewSource(1))
+	dirs := []string{"/usr/lib/", "/usr/share/doc/", "/home/user/src/github.com/", "/var/log/", "/etc/"}
+	words := []string{"go", "mem", "tailscale", "net", "http", "internal", "cmd", "testdata", "x", "pkg"}
+	out := make([]RO, n)
+	for i := range out {
+		p := dirs[r.Intn(len(dirs))]
+		for j := r.Intn(5); j >= 0; j-- {
+			p += words[r.Intn(len(words))] + "/"
+		}
+		p += fmt.Sprintf("file%d.go", r.Intn(n))
+		out[i] = S(p)
+	}
+	return out
+}
+
+// testHostnames returns n pseudo-random hostnames.
+func testHostnames(n int) []RO {
+	r := rand.New(rand.NewSource(2))
+	tlds := []string{".com", ".net", ".org", ".io", ".ts.net"}
+	out := make([]RO, n)
+	for i := range out {
+		out[i] = S(fmt.Sprintf("host-%x.example%d%s", r.Intn(n), r.Intn(10), tlds[r.Intn(len(tlds))]))
+	}
+	return out
+}
+
+func testRandomBytes(n int) []RO {
+	r := rand.New(rand.NewSource(3))
+	out := make([]RO, n)
+	for i := range out {
+		b := make([]byte, r.Intn(4))
+		for j := range b {
+			b[j] = "a\x00\xff"[r.Intn(3)]
+		}
+		out[i] = B(b)
+	}
+	return out
+}
+
+func TestRadixSort(t *testing.T) {
+	inputs := map[string][]RO{
+		"empty":     nil,
+		"one":       {S("x")},
+		"equal":     slices.Repeat([]RO{S("same")}, 100),
+		"empties":   slices.Repeat([]RO{S("")}, 100),
+		"paths":     testPaths(5000),
+		"hostnames": testHostnames(5000),
+		"bytes":     testRandomBytes(5000),
+		"large":     testPaths(3 * radixParallelMin),
+	}
+	sorts := map[string]func([]RO){
+		"RadixSort":         RadixSort,
+		"ParallelRadixSort": ParallelRadixSort,
+	}
+	for name, in := range inputs {
+		want := slices.Clone(in)
+		slices.SortFunc(want, Compare)
+		for sortName, sort := range sorts {
+			got := slices.Clone(in)
+			sort(got)
+			if !slices.EqualFunc(got, want, RO.Equal) {
+				t.Errorf("%s(%s) disagrees with slices.SortFunc", sortName, name)
+			}
+		}
+	}
+}
+
+func BenchmarkSortROs(b *testing.B) {
+	inputs := []struct {
+		name string
+		keys []RO
+	}{
+		{"paths", testPaths(100000)},
+		{"hostnames", testHostnames(100000)},
+	}
+	sorts := []struct {
+		name string
+		sort func([]RO)
+	}{
+		{"SortFunc", func(s []RO) { slices.SortFunc(s, Compare) }},
+		{"SortROs", SortROs},
+		{"RadixSort", RadixSort},
+		{"ParallelRadixSort", ParallelRadixSort},
+	}
+	for _, in := range inputs {
+		for _, sort := range sorts {
+			b.Run(in.name+"/"+sort.name, func(b *testing.B) {
+				s := make([]RO, len(in.keys))
+				for i := 0; i < b.N; i++ {
+					copy(s, in.keys)
+					sort.sort(s)
+				}
+			})
+		}
+	}
+}
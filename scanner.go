@@ -0,0 +1,396 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"bufio"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scanner is like bufio.Scanner, but its tokens are ROs.
+//
+// As with bufio.Scanner.Bytes, a token returned by Token is only
+// valid until the next call to Scan, which may overwrite the memory
+// it views. Use StringCopy or Append to keep it longer.
+//
+// Scanner reports the same errors as bufio.Scanner, such as
+// bufio.ErrTooLong, and split functions may return
+// bufio.ErrFinalToken to stop scanning.
+type Scanner struct {
+	r            io.Reader // The reader provided by the client.
+	split        SplitFunc // The function to split the tokens.
+	maxTokenSize int       // Maximum size of a token.
+	token        RO        // Last token returned by split.
+	buf          []byte    // Buffer used as argument to split.
+	start        int       // First non-processed byte in buf.
+	end          int       // End of data in buf.
+	err          error     // Sticky error.
+	empties      int       // Count of successive empty tokens.
+	scanCalled   bool      // Scan has been called; buffer is in use.
+	done         bool      // Scan has finished.
+	debug        bool      // Poison consumed bytes; see Debug.
+	poisoned     int       // buf[:poisoned] is already poisoned.
+}
+
+// SplitFunc is the signature of the split function used by a Scanner
+// to tokenize its input. It's like bufio.SplitFunc, but because an RO
+// can't be nil, it returns ok to report whether token is a token.
+// Returning (0, RO{}, false, nil) asks the Scanner for more data.
+//
+// data is only valid for the duration of the call. The returned token
+// is typically a view of data.
+type SplitFunc func(data RO, atEOF bool) (advance int, token RO, ok bool, err error)
+
+const (
+	startScanBufSize = 4096 // Size of initial allocation for buffer.
+
+	maxConsecutiveEmptyReads = 100
+
+	// scanPoison is the byte that consumed input is overwritten
+	// with in debug mode.
+	scanPoison = 0xAA
+)
+
+// NewScanner returns a new Scanner to read from r.
+// The split function defaults to ScanLines.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{
+		r:            r,
+		split:        ScanLines,
+		maxTokenSize: bufio.MaxScanTokenSize,
+	}
+}
+
+// Err returns the first non-EOF error that was encountered by the
+// Scanner.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// Token returns the most recent token generated by a call to Scan.
+// It's only valid until the next call to Scan.
+func (s *Scanner) Token() RO { return s.token }
+
+// Text returns the most recent token generated by a call to Scan as a
+// newly allocated string.
+func (s *Scanner) Text() string { return s.token.StringCopy() }
+
+// Buffer sets the initial buffer to use when scanning and the maximum
+// size of buffer that may be allocated during scanning, which bounds
+// the size of a token. It works like bufio.Scanner.Buffer.
+//
+// Buffer panics if it is called after scanning has started.
+func (s *Scanner) Buffer(buf []byte, max int) {
+	if s.scanCalled {
+		panic("Buffer called after Scan")
+	}
+	s.buf = buf[0:cap(buf)]
+	s.maxTokenSize = max
+}
+
+// Split sets the split function for the Scanner.
+// The default split function is ScanLines.
+//
+// Split panics if it is called after scanning has started.
+func (s *Scanner) Split(split SplitFunc) {
+	if s.scanCalled {
+		panic("Split called after Scan")
+	}
+	s.split = split
+}
+
+// Debug sets whether the Scanner poisons input it's done with. When
+// enabled, each call to Scan first overwrites the bytes of previously
+// consumed input, including earlier tokens, so code that holds on to
+// a token past the next Scan sees garbage rather than plausible data.
+// It's meant for tests.
+func (s *Scanner) Debug(enabled bool) { s.debug = enabled }
+
+// poison overwrites b with scanPoison.
+func poison(b []byte) {
+	for i := range b {
+		b[i] = scanPoison
+	}
+}
+
+// Scan advances the Scanner to the next token, which will then be
+// available through the Token method. It returns false when there are
+// no more tokens, either by reaching the end of the input or an
+// error. After Scan returns false, the Err method will return any
+// error that occurred during scanning, except that if it was io.EOF,
+// Err will return nil.
+//
+// Scan panics if the split function returns too many empty tokens
+// without advancing the input.
+func (s *Scanner) Scan() bool {
+	// Copied from the Go standard library (BSD license).
+	if s.done {
+		return false
+	}
+	s.scanCalled = true
+	s.token = RO{}
+	if s.debug && s.poisoned < s.start {
+		poison(s.buf[s.poisoned:s.start])
+		s.poisoned = s.start
+	}
+	// Loop until we have a token.
+	for {
+		// See if we can get a token with what we already have.
+		// If we've run out of data but have an error, give the split
+		// function a chance to recover any remaining, possibly empty
+		// token.
+		if s.end > s.start || s.err != nil {
+			advance, token, ok, err := s.split(B(s.buf[s.start:s.end]), s.err != nil)
+			if err != nil {
+				if err == bufio.ErrFinalToken {
+					s.token = token
+					s.done = true
+					return ok
+				}
+				s.setErr(err)
+				return false
+			}
+			if !s.advance(advance) {
+				return false
+			}
+			if ok {
+				s.token = token
+				if s.err == nil || advance > 0 {
+					s.empties = 0
+				} else {
+					// Returning tokens not advancing input at EOF.
+					s.empties++
+					if s.empties > maxConsecutiveEmptyReads {
+						panic("mem.Scan: too many empty tokens without progressing")
+					}
+				}
+				return true
+			}
+		}
+		// We cannot generate a token with what we are holding.
+		// If we've already hit EOF or an I/O error, we are done.
+		if s.err != nil {
+			s.start = 0
+			s.end = 0
+			s.poisoned = 0
+			return false
+		}
+		// Must read more data.
+		// First, shift data to beginning of buffer if there's lots of
+		// empty space or space is needed.
+		if s.start > 0 && (s.end == len(s.buf) || s.start > len(s.buf)/2) {
+			copy(s.buf, s.buf[s.start:s.end])
+			if s.debug {
+				poison(s.buf[s.end-s.start : s.end])
+			}
+			s.end -= s.start
+			s.start = 0
+			s.poisoned = 0
+		}
+		// Is the buffer full? If so, resize.
+		if s.end == len(s.buf) {
+			// Guarantee no overflow in the multiplication below.
+			const maxInt = int(^uint(0) >> 1)
+			if len(s.buf) >= s.maxTokenSize || len(s.buf) > maxInt/2 {
+				s.setErr(bufio.ErrTooLong)
+				return false
+			}
+			newSize := len(s.buf) * 2
+			if newSize == 0 {
+				newSize = startScanBufSize
+			}
+			if newSize > s.maxTokenSize {
+				newSize = s.maxTokenSize
+			}
+			newBuf := make([]byte, newSize)
+			copy(newBuf, s.buf[s.start:s.end])
+			if s.debug {
+				poison(s.buf)
+			}
+			s.buf = newBuf
+			s.end -= s.start
+			s.start = 0
+			s.poisoned = 0
+		}
+		// Finally we can read some input. Make sure we don't get stuck
+		// with a misbehaving Reader.
+		for loop := 0; ; {
+			n, err := s.r.Read(s.buf[s.end:len(s.buf)])
+			if n < 0 || len(s.buf)-s.end < n {
+				s.setErr(bufio.ErrBadReadCount)
+				break
+			}
+			s.end += n
+			if err != nil {
+				s.setErr(err)
+				break
+			}
+			if n > 0 {
+				s.empties = 0
+				break
+			}
+			loop++
+			if loop > maxConsecutiveEmptyReads {
+				s.setErr(io.ErrNoProgress)
+				break
+			}
+		}
+	}
+}
+
+// advance consumes n bytes of the buffer. It reports whether the
+// advance was legal.
+func (s *Scanner) advance(n int) bool {
+	// Copied from the Go standard library (BSD license).
+	if n < 0 {
+		s.setErr(bufio.ErrNegativeAdvance)
+		return false
+	}
+	if n > s.end-s.start {
+		s.setErr(bufio.ErrAdvanceTooFar)
+		return false
+	}
+	s.start += n
+	return true
+}
+
+// setErr records the first error encountered.
+func (s *Scanner) setErr(err error) {
+	// Copied from the Go standard library (BSD license).
+	if s.err == nil || s.err == io.EOF {
+		s.err = err
+	}
+}
+
+// dropCR drops a terminal \r from m.
+func dropCR(m RO) RO {
+	// Copied from the Go standard library (BSD license).
+	if m.Len() > 0 && m.At(m.Len()-1) == '\r' {
+		return m.SliceTo(m.Len() - 1)
+	}
+	return m
+}
+
+// ScanLines is a split function for a Scanner that returns each line
+// of text, stripped of any trailing end-of-line marker, like
+// bufio.ScanLines.
+func ScanLines(data RO, atEOF bool) (advance int, token RO, ok bool, err error) {
+	// Copied from the Go standard library (BSD license).
+	if atEOF && data.Len() == 0 {
+		return 0, RO{}, false, nil
+	}
+	if i := IndexByte(data, '\n'); i >= 0 {
+		return i + 1, dropCR(data.SliceTo(i)), true, nil
+	}
+	if atEOF {
+		return data.Len(), dropCR(data), true, nil
+	}
+	return 0, RO{}, false, nil
+}
+
+var errorRune = S(string(utf8.RuneError))
+
+// ScanRunes is a split function for a Scanner that returns each
+// UTF-8-encoded rune as a token, like bufio.ScanRunes. Erroneous
+// encodings are returned as U+FFFD.
+func ScanRunes(data RO, atEOF bool) (advance int, token RO, ok bool, err error) {
+	// Copied from the Go standard library (BSD license).
+	if atEOF && data.Len() == 0 {
+		return 0, RO{}, false, nil
+	}
+	if data.At(0) < utf8.RuneSelf {
+		return 1, data.SliceTo(1), true, nil
+	}
+	_, width := DecodeRune(data)
+	if width > 1 {
+		return width, data.SliceTo(width), true, nil
+	}
+	// Either an error or an incomplete rune.
+	if !atEOF && !FullRune(data) {
+		return 0, RO{}, false, nil
+	}
+	return 1, errorRune, true, nil
+}
+
+// isSpace reports whether the rune at the start of m is a space, and
+// its width.
+func isSpace(m RO) (space bool, width int) {
+	if c := m.At(0); c < utf8.RuneSelf {
+		return asciiSpace[c] != 0, 1
+	}
+	r, width := DecodeRune(m)
+	return unicode.IsSpace(r), width
+}
+
+// ScanWords is a split function for a Scanner that returns each
+// space-separated word of text, with surrounding spaces deleted, like
+// bufio.ScanWords. Like AppendFields, it uses a table lookup for
+// ASCII bytes.
+func ScanWords(data RO, atEOF bool) (advance int, token RO, ok bool, err error) {
+	// Copied from the Go standard library (BSD license).
+	// Skip leading spaces.
+	start := 0
+	for start < data.Len() {
+		space, width := isSpace(data.SliceFrom(start))
+		if !space {
+			break
+		}
+		start += width
+	}
+	// Scan until space, marking end of word.
+	for i := start; i < data.Len(); {
+		space, width := isSpace(data.SliceFrom(i))
+		if space {
+			return i + width, data.Slice(start, i), true, nil
+		}
+		i += width
+	}
+	// If we're at EOF, we have a final, non-empty, non-terminated word.
+	if atEOF && data.Len() > start {
+		return data.Len(), data.SliceFrom(start), true, nil
+	}
+	// Request more data.
+	return start, RO{}, false, nil
+}
+
+// ScanDelimited returns a split function for a Scanner that returns
+// the text between occurrences of delim. A final token not followed
+// by delim is returned at EOF if it is non-empty.
+//
+// ScanDelimited panics if delim is empty.
+func ScanDelimited(delim RO) SplitFunc {
+	if delim.Len() == 0 {
+		panic("mem.ScanDelimited: empty delimiter")
+	}
+	return func(data RO, atEOF bool) (advance int, token RO, ok bool, err error) {
+		if atEOF && data.Len() == 0 {
+			return 0, RO{}, false, nil
+		}
+		if i := Index(data, delim); i >= 0 {
+			return i + delim.Len(), data.SliceTo(i), true, nil
+		}
+		if atEOF {
+			return data.Len(), data, true, nil
+		}
+		return 0, RO{}, false, nil
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var scanInputs = []string{
+	"",
+	"\n",
+	"one line",
+	"two\nlines\n",
+	"crlf\r\nlines\r\n\r\n",
+	"  leading and  trailing spaces  ",
+	"unicode spaces　here ☺☻☹",
+	"bad\xffutf8\xe2\x82",
+	strings.Repeat("long line of text ", 1000) + "\nshort",
+}
+
+func TestScannerMatchesBufio(t *testing.T) {
+	splits := []struct {
+		name  string
+		mem   SplitFunc
+		bufio bufio.SplitFunc
+	}{
+		{"lines", ScanLines, bufio.ScanLines},
+		{"words", ScanWords, bufio.ScanWords},
+		{"runes", ScanRunes, bufio.ScanRunes},
+	}
+	for _, sp := range splits {
+		for _, in := range scanInputs {
+			var want []string
+			bs := bufio.NewScanner(strings.NewReader(in))
+			bs.Split(sp.bufio)
+			for bs.Scan() {
+				want = append(want, bs.Text())
+			}
+
+			for _, oneByte := range []bool{false, true} {
+				var r io.Reader = strings.NewReader(in)
+				if oneByte {
+					r = iotest.OneByteReader(r)
+				}
+				var got []string
+				s := NewScanner(r)
+				s.Split(sp.mem)
+				s.Debug(true)
+				for s.Scan() {
+					got = append(got, s.Text())
+				}
+				if s.Err() != nil {
+					t.Errorf("%s(%.20q): Err = %v", sp.name, in, s.Err())
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s(%.20q), oneByte=%v: got %q; want %q", sp.name, in, oneByte, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestScanDelimited(t *testing.T) {
+	s := NewScanner(iotest.OneByteReader(strings.NewReader("a--b----c--")))
+	s.Split(ScanDelimited(S("--")))
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Text())
+	}
+	if want := []string{"a", "b", "", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestScannerTooLong(t *testing.T) {
+	s := NewScanner(strings.NewReader("short\n" + strings.Repeat("x", 100) + "\n"))
+	s.Buffer(nil, 50)
+	if !s.Scan() || !s.Token().EqualString("short") {
+		t.Fatalf("first Scan = %q", s.Text())
+	}
+	if s.Scan() {
+		t.Fatalf("second Scan succeeded with %q", s.Text())
+	}
+	if s.Err() != bufio.ErrTooLong {
+		t.Errorf("Err = %v; want ErrTooLong", s.Err())
+	}
+}
+
+func TestScannerFinalToken(t *testing.T) {
+	s := NewScanner(strings.NewReader("a b STOP c"))
+	s.Split(func(data RO, atEOF bool) (int, RO, bool, error) {
+		advance, token, ok, err := ScanWords(data, atEOF)
+		if ok && token.EqualString("STOP") {
+			return advance, token, true, bufio.ErrFinalToken
+		}
+		return advance, token, ok, err
+	})
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Text())
+	}
+	if want := []string{"a", "b", "STOP"}; !reflect.DeepEqual(got, want) || s.Err() != nil {
+		t.Errorf("got %q, %v; want %q, nil", got, s.Err(), want)
+	}
+}
+
+func TestScannerDebugPoison(t *testing.T) {
+	s := NewScanner(strings.NewReader("first\nsecond\n"))
+	s.Debug(true)
+	s.Scan()
+	first := s.Token()
+	s.Scan()
+	if memDebug {
+		// The memdebug checks catch the stale token first.
+		defer func() {
+			if recover() == nil {
+				t.Error("reading a stale token didn't panic in memdebug build")
+			}
+		}()
+	}
+	if first.EqualString("first") {
+		t.Fatal("retained token still looks valid after Scan in debug mode")
+	}
+	for i := 0; i < first.Len(); i++ {
+		if first.At(i) != scanPoison {
+			t.Fatalf("retained token = %q; want poison bytes", first.StringCopy())
+		}
+	}
+	if !s.Token().EqualString("second") {
+		t.Errorf("second token = %q", s.Text())
+	}
+}
+
+func TestScannerAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	r := strings.NewReader("")
+	buf := make([]byte, 100)
+	n := int(testing.AllocsPerRun(1000, func() {
+		r.Reset("foo bar\nbaz\n")
+		s := NewScanner(r)
+		s.Buffer(buf, len(buf))
+		for s.Scan() {
+		}
+	}))
+	// At most the Scanner itself; tokens don't allocate.
+	if n > 1 {
+		t.Fatalf("allocs = %d; want at most 1", n)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Searcher finds a single pattern in ROs. Unlike Index, which
+// preprocesses its pattern on every call, a Searcher does so once, so
+// it's suited to searching for the same pattern many times.
+//
+// A Searcher is immutable once built and safe for concurrent use.
+type Searcher struct {
+	pat  string
+	fold bool
+
+	// For byte-for-byte matching, which both kinds of search use, the
+	// Boyer-Moore-Horspool shift tables: how far the window can move
+	// when its last (skip) or first (rskip) byte is c.
+	skip, rskip [256]int
+
+	// For case-folding searches, the bytes that can start a folded
+	// match.
+	first [256]bool
+}
+
+// NewSearcher returns a Searcher for pattern, which is copied.
+func NewSearcher(pattern RO) *Searcher {
+	sr := &Searcher{pat: pattern.StringCopy()}
+	n := len(sr.pat)
+	for c := range sr.skip {
+		sr.skip[c] = n
+		sr.rskip[c] = n
+	}
+	for i := 0; i < n-1; i++ {
+		sr.skip[sr.pat[i]] = n - 1 - i
+	}
+	for i := n - 1; i > 0; i-- {
+		sr.rskip[sr.pat[i]] = i
+	}
+	return sr
+}
+
+// NewSearcherFold returns a Searcher for pattern, which is copied,
+// that matches using Unicode case-folding, like IndexFold. As with
+// IndexFold, a match may have a different length than pattern.
+func NewSearcherFold(pattern RO) *Searcher {
+	// The exact tables find byte-for-byte matches, which always count.
+	sr := NewSearcher(pattern)
+	sr.fold = true
+	if pattern.Len() == 0 {
+		return sr
+	}
+	// Record the first byte of every rune that the pattern's first
+	// rune folds with, and of the pattern itself.
+	sr.first[sr.pat[0]] = true
+	r := firstRune(pattern)
+	f := r
+	for {
+		var buf [utf8.UTFMax]byte
+		utf8.EncodeRune(buf[:], f)
+		sr.first[buf[0]] = true
+		if f = unicode.SimpleFold(f); f == r {
+			break
+		}
+	}
+	return sr
+}
+
+// Pattern returns the pattern sr searches for.
+func (sr *Searcher) Pattern() string { return sr.pat }
+
+// index returns the offset and length of the first match in m, or
+// -1, 0.
+func (sr *Searcher) index(m RO) (i, n int) {
+	if len(sr.pat) == 0 {
+		return 0, 0
+	}
+	s := m.m
+	end := sr.indexExact(m)
+	if !sr.fold || end == 0 {
+		if end < 0 {
+			return -1, 0
+		}
+		return end, len(sr.pat)
+	}
+	// As in indexFold, an exact match bounds where the first folded
+	// match can start, and folded matches start on rune boundaries.
+	if end < 0 {
+		end = len(s)
+	}
+	for i := 0; i < end; {
+		c := s[i]
+		size := 1
+		if c >= utf8.RuneSelf {
+			_, size = utf8.DecodeRuneInString(string(s[i:]))
+		}
+		if sr.first[c] {
+			if n, ok := prefixFoldLen(RO{m: s[i:]}, S(sr.pat)); ok {
+				return i, n
+			}
+		}
+		i += size
+	}
+	if end < len(s) {
+		return end, len(sr.pat)
+	}
+	return -1, 0
+}
+
+// indexExact returns the offset of the first byte-for-byte match in
+// m, or -1.
+func (sr *Searcher) indexExact(m RO) int {
+	s := m.m
+	n := len(sr.pat)
+	last := sr.pat[n-1]
+	for i := 0; i+n <= len(s); {
+		c := s[i+n-1]
+		if c == last && string(s[i:i+n-1]) == sr.pat[:n-1] {
+			return i
+		}
+		i += sr.skip[c]
+	}
+	return -1
+}
+
+// Index returns the index of the first match of sr's pattern in m, or
+// -1 if there is none.
+func (sr *Searcher) Index(m RO) int {
+	i, _ := sr.index(m)
+	return i
+}
+
+// LastIndex returns the index of the last match of sr's pattern in m,
+// or -1 if there is none.
+func (sr *Searcher) LastIndex(m RO) int {
+	if len(sr.pat) == 0 {
+		return m.Len()
+	}
+	start := sr.lastIndexExact(m)
+	if !sr.fold {
+		return start
+	}
+	// As in LastIndexFold, an exact match bounds where the last
+	// folded match can start.
+	s := m.m
+	for i := len(s); i > start+1; {
+		_, size := utf8.DecodeLastRuneInString(string(s[:i]))
+		i -= size
+		if i <= start {
+			break
+		}
+		if !sr.first[s[i]] {
+			continue
+		}
+		if _, ok := prefixFoldLen(RO{m: s[i:]}, S(sr.pat)); ok {
+			return i
+		}
+	}
+	return start
+}
+
+// lastIndexExact returns the offset of the last byte-for-byte match
+// in m, or -1.
+func (sr *Searcher) lastIndexExact(m RO) int {
+	s := m.m
+	n := len(sr.pat)
+	first := sr.pat[0]
+	for i := len(s) - n; i >= 0; {
+		c := s[i]
+		if c == first && string(s[i+1:i+n]) == sr.pat[1:] {
+			return i
+		}
+		i -= sr.rskip[c]
+	}
+	return -1
+}
+
+// Count returns the number of non-overlapping matches of sr's pattern
+// in m. If the pattern is empty, Count returns 1 + the number of runes
+// in m.
+func (sr *Searcher) Count(m RO) int {
+	if len(sr.pat) == 0 {
+		return RuneCount(m) + 1
+	}
+	c := 0
+	for {
+		i, n := sr.index(m)
+		if i < 0 {
+			return c
+		}
+		c++
+		m = m.SliceFrom(i + n)
+	}
+}
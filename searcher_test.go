@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSearcher(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randString := func(n int, alphabet string) string {
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			sb.WriteByte(alphabet[r.Intn(len(alphabet))])
+		}
+		return sb.String()
+	}
+	for iter := 0; iter < 2000; iter++ {
+		pat := randString(r.Intn(6), "abcab")
+		s := randString(r.Intn(50), "abcd")
+		sr := NewSearcher(S(pat))
+		if got, want := sr.Index(S(s)), strings.Index(s, pat); got != want {
+			t.Fatalf("Searcher(%q).Index(%q) = %d; want %d", pat, s, got, want)
+		}
+		if got, want := sr.LastIndex(S(s)), strings.LastIndex(s, pat); got != want {
+			t.Fatalf("Searcher(%q).LastIndex(%q) = %d; want %d", pat, s, got, want)
+		}
+		if got, want := sr.Count(S(s)), strings.Count(s, pat); got != want {
+			t.Fatalf("Searcher(%q).Count(%q) = %d; want %d", pat, s, got, want)
+		}
+	}
+}
+
+func TestSearcherFold(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	alphabet := []string{"a", "A", "k", "K", "K", "s", "S", "ſ", "\xff", "x", "逨", "\xe9", "\x80", "\xa8"}
+	randString := func(n int) string {
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			sb.WriteString(alphabet[r.Intn(len(alphabet))])
+		}
+		return sb.String()
+	}
+	check := func(pat, s RO) {
+		t.Helper()
+		sr := NewSearcherFold(pat)
+		if got, want := sr.Index(s), IndexFold(s, pat); got != want {
+			t.Fatalf("SearcherFold(%q).Index(%q) = %d; want %d", pat.StringCopy(), s.StringCopy(), got, want)
+		}
+		if got, want := sr.LastIndex(s), LastIndexFold(s, pat); got != want {
+			t.Fatalf("SearcherFold(%q).LastIndex(%q) = %d; want %d", pat.StringCopy(), s.StringCopy(), got, want)
+		}
+		if got, want := sr.Count(s), CountFold(s, pat); got != want {
+			t.Fatalf("SearcherFold(%q).Count(%q) = %d; want %d", pat.StringCopy(), s.StringCopy(), got, want)
+		}
+	}
+	check(S("\x80"), S("逨"))
+	check(S("\x80\xa8K"), S("逨k"))
+	for iter := 0; iter < 2000; iter++ {
+		check(S(randString(r.Intn(4))), S(randString(r.Intn(30))))
+	}
+}
+
+func BenchmarkSearcher(b *testing.B) {
+	text := S(strings.Repeat("2020-01-01T00:00:00Z INFO request handled in 3ms path=/api/v1/things\n", 2000) + "needle: the quick brown fox jumps over")
+	pat := S("the quick brown fox jumps over")
+	b.Run("Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if Index(text, pat) < 0 {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("Searcher", func(b *testing.B) {
+		sr := NewSearcher(pat)
+		for i := 0; i < b.N; i++ {
+			if sr.Index(text) < 0 {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("IndexFold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if IndexFold(text, pat) < 0 {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("SearcherFold", func(b *testing.B) {
+		sr := NewSearcherFold(pat)
+		for i := 0; i < b.N; i++ {
+			if sr.Index(text) < 0 {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
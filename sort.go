@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "sort"
+
+// roSlice attaches the methods of sort.Interface to []RO, sorting in
+// increasing byte order.
+type roSlice []RO
+
+func (s roSlice) Len() int           { return len(s) }
+func (s roSlice) Less(i, j int) bool { return s[i].Less(s[j]) }
+func (s roSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// SortROs sorts s in increasing byte order, as defined by Compare.
+func SortROs(s []RO) { sort.Sort(roSlice(s)) }
+
+// BinarySearchRO searches for target in s, which must be sorted in
+// increasing byte order. It returns the index where target is found,
+// or where it would be inserted, and whether it was found.
+func BinarySearchRO(s []RO, target RO) (int, bool) {
+	i := sort.Search(len(s), func(i int) bool { return Compare(s[i], target) >= 0 })
+	return i, i < len(s) && s[i].Equal(target)
+}
+
+// CompactROs replaces runs of equal consecutive elements of s with a
+// single copy and returns the shortened slice. For a sorted s, that
+// removes all duplicates. Elements between the new length and the
+// original length are zeroed.
+func CompactROs(s []RO) []RO {
+	if len(s) < 2 {
+		return s
+	}
+	n := 1
+	for i := 1; i < len(s); i++ {
+		if !s[i].Equal(s[n-1]) {
+			s[n] = s[i]
+			n++
+		}
+	}
+	for i := n; i < len(s); i++ {
+		s[i] = RO{}
+	}
+	return s[:n]
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import "testing"
+
+func TestSortROs(t *testing.T) {
+	s := AppendFields(nil, S("pear apple fig apple banana fig fig"))
+	SortROs(s)
+	if want := []string{"apple", "apple", "banana", "fig", "fig", "fig", "pear"}; !eq(s, want) {
+		t.Fatalf("SortROs = %q; want %q", strs(s), want)
+	}
+
+	for _, tt := range []struct {
+		target string
+		i      int
+		found  bool
+	}{
+		{"", 0, false},
+		{"apple", 0, true},
+		{"b", 2, false},
+		{"fig", 3, true},
+		{"zebra", 7, false},
+	} {
+		i, found := BinarySearchRO(s, S(tt.target))
+		if i != tt.i || found != tt.found {
+			t.Errorf("BinarySearchRO(%q) = %d, %v; want %d, %v", tt.target, i, found, tt.i, tt.found)
+		}
+	}
+
+	full := s[:cap(s)]
+	s = CompactROs(s)
+	if want := []string{"apple", "banana", "fig", "pear"}; !eq(s, want) {
+		t.Fatalf("CompactROs = %q; want %q", strs(s), want)
+	}
+	for _, m := range full[len(s):] {
+		if m.Len() != 0 {
+			t.Errorf("CompactROs left %q in the tail", m.StringCopy())
+		}
+	}
+	if got := CompactROs(nil); len(got) != 0 {
+		t.Errorf("CompactROs(nil) = %q", strs(got))
+	}
+}
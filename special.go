@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The functions in this file are variants of the case mapping and
+// folding functions that follow the language-specific rules of a
+// unicode.SpecialCase, such as unicode.TurkishCase, under which 'I'
+// folds with dotless 'ı' rather than with 'i'.
+//
+// Under a SpecialCase c, two runes fold together when they map to
+// the same rune after being mapped to upper case and then lower case
+// with c.
+
+// AppendToLowerSpecial is like AppendToLower, but uses the case
+// mapping specified by c, like strings.ToLowerSpecial.
+func AppendToLowerSpecial(dst []byte, c unicode.SpecialCase, m RO) []byte {
+	return AppendMap(dst, c.ToLower, m)
+}
+
+// AppendToUpperSpecial is like AppendToUpper, but uses the case
+// mapping specified by c, like strings.ToUpperSpecial.
+func AppendToUpperSpecial(dst []byte, c unicode.SpecialCase, m RO) []byte {
+	return AppendMap(dst, c.ToUpper, m)
+}
+
+// AppendToTitleSpecial is like AppendToTitle, but uses the case
+// mapping specified by c, like strings.ToTitleSpecial.
+func AppendToTitleSpecial(dst []byte, c unicode.SpecialCase, m RO) []byte {
+	return AppendMap(dst, c.ToTitle, m)
+}
+
+// foldRuneSpecial returns the rune that r folds to under c.
+func foldRuneSpecial(c unicode.SpecialCase, r rune) rune {
+	return c.ToLower(c.ToUpper(r))
+}
+
+// prefixFoldLenSpecial is like prefixFoldLen, but folds under c.
+func prefixFoldLenSpecial(c unicode.SpecialCase, s, prefix RO) (n int, ok bool) {
+	if strings.HasPrefix(s.str(), prefix.str()) {
+		return prefix.Len(), true
+	}
+	for p := prefix.str(); p != ""; {
+		if n == s.Len() {
+			return 0, false
+		}
+		pr, psize := decodeFoldRune(p)
+		p = p[psize:]
+		sr, size := decodeFoldRune(s.str()[n:])
+		n += size
+		if !equalFoldRuneSpecial(c, sr, pr) {
+			return 0, false
+		}
+	}
+	return n, true
+}
+
+// equalFoldRuneSpecial reports whether sr and tr fold equally under
+// c. As with equalFoldRune, the negative runes decodeFoldRune returns
+// for invalid UTF-8 bytes only match themselves.
+func equalFoldRuneSpecial(c unicode.SpecialCase, sr, tr rune) bool {
+	if sr == tr {
+		return true
+	}
+	return sr >= 0 && tr >= 0 && foldRuneSpecial(c, sr) == foldRuneSpecial(c, tr)
+}
+
+// EqualFoldSpecial is like EqualFold, but folds case using the rules
+// of c.
+//
+// As with EqualFold, invalid UTF-8 bytes compare as U+FFFD.
+func EqualFoldSpecial(c unicode.SpecialCase, s, t RO) bool {
+	a, b := s.str(), t.str()
+	for a != "" && b != "" {
+		sr, size := utf8.DecodeRuneInString(a)
+		a = a[size:]
+		tr, size := utf8.DecodeRuneInString(b)
+		b = b[size:]
+		if sr != tr && foldRuneSpecial(c, sr) != foldRuneSpecial(c, tr) {
+			return false
+		}
+	}
+	return a == b
+}
+
+// HasPrefixFoldSpecial is like HasPrefixFold, but folds case using
+// the rules of c.
+func HasPrefixFoldSpecial(c unicode.SpecialCase, s, prefix RO) bool {
+	_, ok := prefixFoldLenSpecial(c, s, prefix)
+	return ok
+}
+
+// HasSuffixFoldSpecial is like HasSuffixFold, but folds case using
+// the rules of c.
+func HasSuffixFoldSpecial(c unicode.SpecialCase, s, suffix RO) bool {
+	if strings.HasSuffix(s.str(), suffix.str()) {
+		return true
+	}
+	bo, so := s.Len(), suffix.Len()
+	for bo > 0 && so > 0 {
+		r, size := decodeLastFoldRune(s.str()[:bo])
+		bo -= size
+		sr, size := decodeLastFoldRune(suffix.str()[:so])
+		so -= size
+		if !equalFoldRuneSpecial(c, r, sr) {
+			return false
+		}
+	}
+	return so == 0
+}
+
+// IndexFoldSpecial is like IndexFold, but folds case using the rules
+// of c.
+func IndexFoldSpecial(c unicode.SpecialCase, s, substr RO) int {
+	if substr.Len() == 0 {
+		return 0
+	}
+	// An exact match bounds where the first folded match can start.
+	end := strings.Index(s.str(), substr.str())
+	if end < 0 {
+		end = s.Len()
+	}
+	fr := foldRuneSpecial(c, firstRune(substr))
+	for i, r := range s.str()[:end] {
+		if foldRuneSpecial(c, r) != fr {
+			continue
+		}
+		if _, ok := prefixFoldLenSpecial(c, s.SliceFrom(i), substr); ok {
+			return i
+		}
+	}
+	if end < s.Len() {
+		return end
+	}
+	return -1
+}
+
+// ContainsFoldSpecial is like ContainsFold, but folds case using the
+// rules of c.
+func ContainsFoldSpecial(c unicode.SpecialCase, s, substr RO) bool {
+	return IndexFoldSpecial(c, s, substr) >= 0
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+var specialCases = map[string]unicode.SpecialCase{
+	"TurkishCase": unicode.TurkishCase,
+	"AzeriCase":   unicode.AzeriCase,
+}
+
+// TestSpecialCaseTables checks every entry in the standard library's
+// special-case tables.
+func TestSpecialCaseTables(t *testing.T) {
+	for name, c := range specialCases {
+		for _, cr := range c {
+			for r := rune(cr.Lo); r <= rune(cr.Hi); r++ {
+				s := string(r) + " x" + string(r)
+				if got, want := string(AppendToLowerSpecial(nil, c, S(s))), strings.ToLowerSpecial(c, s); got != want {
+					t.Errorf("%s: AppendToLowerSpecial(%q) = %q; want %q", name, s, got, want)
+				}
+				if got, want := string(AppendToUpperSpecial(nil, c, S(s))), strings.ToUpperSpecial(c, s); got != want {
+					t.Errorf("%s: AppendToUpperSpecial(%q) = %q; want %q", name, s, got, want)
+				}
+				if got, want := string(AppendToTitleSpecial(nil, c, S(s))), strings.ToTitleSpecial(c, s); got != want {
+					t.Errorf("%s: AppendToTitleSpecial(%q) = %q; want %q", name, s, got, want)
+				}
+				for _, mapped := range []rune{c.ToLower(r), c.ToUpper(r), c.ToTitle(r)} {
+					if !EqualFoldSpecial(c, S(string(r)), S(string(mapped))) {
+						t.Errorf("%s: EqualFoldSpecial(%q, %q) = false", name, r, mapped)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFoldSpecial(t *testing.T) {
+	tr := unicode.TurkishCase
+	bind := func(f func(unicode.SpecialCase, RO, RO) bool) func(RO, RO) bool {
+		return func(a, b RO) bool { return f(tr, a, b) }
+	}
+	runFoldTests(t, bind(EqualFoldSpecial), "EqualFoldSpecial", []foldTest{
+		{"", "", true},
+		{"I", "ı", true},
+		{"İ", "i", true},
+		{"I", "i", false},
+		{"İ", "ı", false},
+		{"DİYARBAKIR", "diyarbakır", true},
+		{"DIYARBAKIR", "diyarbakır", false},
+		{"Straße", "STRASSE", false},
+		{"k", "K", true},
+		{"ab", "a", false},
+		{"a", "ab", false},
+	})
+	runFoldTests(t, bind(HasPrefixFoldSpecial), "HasPrefixFoldSpecial", []foldTest{
+		{"İSTANBUL", "ist", true},
+		{"ISTANBUL", "ist", false},
+		{"ist", "İSTANBUL", false},
+		{"foo", "", true},
+	})
+	runFoldTests(t, bind(HasSuffixFoldSpecial), "HasSuffixFoldSpecial", []foldTest{
+		{"diyarbakır", "KIR", true},
+		{"diyarbakir", "KIR", false},
+		{"foo", "", true},
+		{"ır", "KIR", false},
+	})
+	runFoldTests(t, bind(ContainsFoldSpecial), "ContainsFoldSpecial", []foldTest{
+		{"hello İzmir", "izmir", true},
+		{"hello Izmir", "izmir", false},
+		{"hello Izmir", "ızmir", true},
+		{"", "", true},
+	})
+	if got := IndexFoldSpecial(tr, S("şehir: İzmir"), S("izmir")); got != 8 {
+		t.Errorf("IndexFoldSpecial = %d; want 8", got)
+	}
+
+	// Invalid UTF-8 follows the same rules as the default functions.
+	runFoldTests(t, bind(EqualFoldSpecial), "EqualFoldSpecial", []foldTest{
+		{"\xff", "\ufffd", true},
+		{"\xff", "\xfe", true},
+	})
+	runFoldTests(t, bind(HasPrefixFoldSpecial), "HasPrefixFoldSpecial", []foldTest{
+		{"\xe9\x80\xa8", "\xe9\x80", true},
+		{"\xffI", "\xffı", true},
+		{"\xff", "\xfe", false},
+		{"\xff", "\ufffd", false},
+	})
+	runFoldTests(t, bind(HasSuffixFoldSpecial), "HasSuffixFoldSpecial", []foldTest{
+		{"\xe9\x80\xa8", "\x80\xa8", true},
+		{"\xff", "\xfe", false},
+	})
+	for _, tt := range []struct {
+		s, substr string
+		want      int
+	}{
+		{"\xe9\x80\xa8", "\x80", 1},
+		{"A\xffI\xff", "ı\xff", 2},
+		{"\xfe", "\xff", -1},
+		{"\xff", "\ufffd", -1},
+	} {
+		if got := IndexFoldSpecial(tr, S(tt.s), S(tt.substr)); got != tt.want {
+			t.Errorf("IndexFoldSpecial(%q, %q) = %d; want %d", tt.s, tt.substr, got, tt.want)
+		}
+	}
+
+	// The default functions are unchanged.
+	if EqualFold(S("I"), S("ı")) || !EqualFold(S("I"), S("i")) {
+		t.Errorf("EqualFold changed behavior for Turkish I")
+	}
+}
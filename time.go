@@ -0,0 +1,305 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// ParseTime is like time.Parse, but parses m.
+//
+// Neither the returned time's location nor a returned
+// *time.ParseError refers to m's memory, which the caller may later
+// modify.
+func ParseTime(layout string, m RO) (time.Time, error) {
+	m.check()
+	t, err := time.Parse(layout, m.str())
+	if err != nil {
+		if pe, ok := err.(*time.ParseError); ok {
+			pe.Value = m.StringCopy()
+			pe.ValueElem = string(append([]byte(nil), pe.ValueElem...))
+		}
+		return t, err
+	}
+	// Not all versions of package time copy the zone abbreviation
+	// out of the value before naming a fixed zone after it.
+	if name, offset := t.Zone(); name != "" && aliases(m, name) {
+		t = t.In(time.FixedZone(string(append([]byte(nil), name...)), offset))
+	}
+	return t, nil
+}
+
+// aliases reports whether s points into m's memory.
+func aliases(m RO, s string) bool {
+	if m.Len() == 0 || len(s) == 0 {
+		return false
+	}
+	ms := m.str()
+	start := uintptr(unsafe.Pointer((*stringHeader)(unsafe.Pointer(&ms)).P))
+	p := uintptr(unsafe.Pointer((*stringHeader)(unsafe.Pointer(&s)).P))
+	return start <= p && p < start+uintptr(len(ms))
+}
+
+// ParseRFC3339 is ParseTime(time.RFC3339, m), which also accepts
+// fractional seconds as in time.RFC3339Nano. Timestamps in the usual
+// form, with up to nine fractional digits, are parsed without
+// allocating; anything else is left to time.Parse.
+func ParseRFC3339(m RO) (time.Time, error) {
+	m.check()
+	if t, ok := parseRFC3339(m.str()); ok {
+		return t, nil
+	}
+	return ParseTime(time.RFC3339, m)
+}
+
+func parseRFC3339(s string) (time.Time, bool) {
+	if len(s) < len("2006-01-02T15:04:05Z") {
+		return time.Time{}, false
+	}
+	year, ok1 := atoiRange(s[0:4], 0, 9999)
+	month, ok2 := atoiRange(s[5:7], 1, 12)
+	day, ok3 := atoiRange(s[8:10], 1, 31)
+	hour, ok4 := atoiRange(s[11:13], 0, 23)
+	min, ok5 := atoiRange(s[14:16], 0, 59)
+	sec, ok6 := atoiRange(s[17:19], 0, 59)
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6) ||
+		s[4] != '-' || s[7] != '-' || s[10] != 'T' || s[13] != ':' || s[16] != ':' ||
+		day > daysIn(time.Month(month), year) {
+		return time.Time{}, false
+	}
+	s = s[19:]
+
+	nsec := 0
+	if s[0] == '.' {
+		n := 1
+		for n < len(s) && '0' <= s[n] && s[n] <= '9' {
+			n++
+		}
+		if n == 1 || n > 10 {
+			return time.Time{}, false
+		}
+		nsec, _ = atoiRange(s[1:n], 0, 999999999)
+		for i := n; i < 10; i++ {
+			nsec *= 10
+		}
+		s = s[n:]
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, min, sec, nsec, time.UTC)
+	if s == "Z" {
+		return t, true
+	}
+	if len(s) != len("-07:00") || s[0] != '+' && s[0] != '-' || s[3] != ':' {
+		return time.Time{}, false
+	}
+	hr, ok1 := atoiRange(s[1:3], 0, 23)
+	mm, ok2 := atoiRange(s[4:6], 0, 59)
+	if !ok1 || !ok2 {
+		return time.Time{}, false
+	}
+	offset := (hr*60 + mm) * 60
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return withOffset(t, offset), true
+}
+
+// ParseRFC1123 is ParseTime(time.RFC1123, m). Timestamps in the usual
+// form in UTC are parsed without allocating; anything else, including
+// other zone abbreviations such as GMT, is left to time.Parse.
+func ParseRFC1123(m RO) (time.Time, error) {
+	m.check()
+	if t, ok := parseRFC1123(m.str()); ok {
+		return t, nil
+	}
+	return ParseTime(time.RFC1123, m)
+}
+
+func parseRFC1123(s string) (time.Time, bool) {
+	// Mon, 02 Jan 2006 15:04:05 UTC
+	if len(s) != len(time.RFC1123) || s[3] != ',' || s[4] != ' ' || s[7] != ' ' || s[11] != ' ' ||
+		s[16] != ' ' || s[25] != ' ' || s[26:] != "UTC" || !isWeekday(s[0:3]) {
+		return time.Time{}, false
+	}
+	day, ok1 := atoiRange(s[5:7], 1, 31)
+	month := monthIndex(s[8:11])
+	year, ok2 := atoiRange(s[12:16], 0, 9999)
+	if !ok1 || !ok2 || month == 0 || day > daysIn(time.Month(month), year) {
+		return time.Time{}, false
+	}
+	hour, min, sec, ok := parseClock(s[17:25])
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month(month), day, hour, min, sec, 0, time.UTC), true
+}
+
+// ParseStamp is ParseTime(time.Stamp, m), parsing syslog-style
+// timestamps such as "Jan _2 15:04:05". As with time.Parse, the year
+// of the result is 0 and its location UTC. Timestamps in the usual
+// form are parsed without allocating; anything else is left to
+// time.Parse.
+func ParseStamp(m RO) (time.Time, error) {
+	m.check()
+	if t, ok := parseStamp(m.str()); ok {
+		return t, nil
+	}
+	return ParseTime(time.Stamp, m)
+}
+
+func parseStamp(s string) (time.Time, bool) {
+	// Jan _2 15:04:05
+	if len(s) != len(time.Stamp) || s[3] != ' ' || s[6] != ' ' {
+		return time.Time{}, false
+	}
+	month := monthIndex(s[0:3])
+	d := s[4:6]
+	if d[0] == ' ' {
+		d = d[1:]
+	}
+	day, ok := atoiRange(d, 1, 31)
+	if !ok || month == 0 || day > daysIn(time.Month(month), 0) {
+		return time.Time{}, false
+	}
+	hour, min, sec, ok := parseClock(s[7:])
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Date(0, time.Month(month), day, hour, min, sec, 0, time.UTC), true
+}
+
+// ParseUnix parses m as a decimal number of seconds since the Unix
+// epoch, returning the corresponding local time as time.Unix does.
+// Errors are those of TryParseInt.
+func ParseUnix(m RO) (time.Time, error) {
+	sec, err := TryParseInt(m, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// ParseUnixMilli parses m as a decimal number of milliseconds since
+// the Unix epoch, returning the corresponding local time as
+// time.UnixMilli does. Errors are those of TryParseInt.
+func ParseUnixMilli(m RO) (time.Time, error) {
+	msec, err := TryParseInt(m, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(msec/1e3, (msec%1e3)*1e6), nil
+}
+
+// atoiRange parses s, which must be all decimal digits, and checks
+// that the result is in [min, max].
+func atoiRange(s string, min, max int) (int, bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	x := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || '9' < c {
+			return 0, false
+		}
+		x = x*10 + int(c-'0')
+	}
+	return x, min <= x && x <= max
+}
+
+// parseClock parses "15:04:05".
+func parseClock(s string) (hour, min, sec int, ok bool) {
+	if len(s) != len("15:04:05") || s[2] != ':' || s[5] != ':' {
+		return 0, 0, 0, false
+	}
+	hour, ok1 := atoiRange(s[0:2], 0, 23)
+	min, ok2 := atoiRange(s[3:5], 0, 59)
+	sec, ok3 := atoiRange(s[6:8], 0, 59)
+	return hour, min, sec, ok1 && ok2 && ok3
+}
+
+const monthNames = "JanFebMarAprMayJunJulAugSepOctNovDec"
+
+// monthIndex returns the month abbreviated as s, or 0 if s isn't
+// one. Unlike time.Parse, it's case sensitive; the slow path handles
+// other cases.
+func monthIndex(s string) int {
+	for i := 0; i < len(monthNames); i += 3 {
+		if monthNames[i:i+3] == s {
+			return i/3 + 1
+		}
+	}
+	return 0
+}
+
+func isWeekday(s string) bool {
+	switch s {
+	case "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun":
+		return true
+	}
+	return false
+}
+
+var daysInMonth = [...]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+func daysIn(m time.Month, year int) int {
+	if m == time.February && year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 29
+	}
+	return daysInMonth[m-1]
+}
+
+// withOffset returns the instant t, a wall clock time read as UTC,
+// in a zone offset seconds east of UTC, choosing the location the way
+// time.Parse does: Local if its offset at that instant matches, or
+// else a zone with no name.
+func withOffset(t time.Time, offset int) time.Time {
+	t = t.Add(-time.Duration(offset) * time.Second)
+	if _, off := t.In(time.Local).Zone(); off == offset {
+		return t.In(time.Local)
+	}
+	return t.In(fixedZone(offset))
+}
+
+var fixedZones struct {
+	sync.RWMutex
+	m map[int]*time.Location
+}
+
+// fixedZone returns time.FixedZone("", offset), caching it so that
+// ParseRFC3339 doesn't allocate a new one for each timestamp.
+func fixedZone(offset int) *time.Location {
+	fixedZones.RLock()
+	loc := fixedZones.m[offset]
+	fixedZones.RUnlock()
+	if loc != nil {
+		return loc
+	}
+	fixedZones.Lock()
+	defer fixedZones.Unlock()
+	if loc := fixedZones.m[offset]; loc != nil {
+		return loc
+	}
+	if fixedZones.m == nil {
+		fixedZones.m = make(map[int]*time.Location)
+	}
+	loc = time.FixedZone("", offset)
+	fixedZones.m[offset] = loc
+	return loc
+}
@@ -0,0 +1,285 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// sameTime reports whether a and b, results of parsing, are
+// indistinguishable: the same instant, zone and kind of location.
+func sameTime(a, b time.Time) bool {
+	locKind := func(t time.Time) string {
+		switch t.Location() {
+		case time.UTC:
+			return "UTC"
+		case time.Local:
+			return "Local"
+		}
+		return "fixed"
+	}
+	an, ao := a.Zone()
+	bn, bo := b.Zone()
+	return a.Equal(b) && an == bn && ao == bo && locKind(a) == locKind(b)
+}
+
+// withLocal runs f with time.Local set to loc.
+func withLocal(loc *time.Location, f func()) {
+	old := time.Local
+	defer func() { time.Local = old }()
+	time.Local = loc
+	f()
+}
+
+var testLocals = []*time.Location{
+	time.UTC,
+	time.FixedZone("CET", 3600),
+	time.FixedZone("IST", 5*3600+30*60),
+}
+
+// checkParse checks that parse(s) matches time.Parse(layout, s), in
+// each of testLocals.
+func checkParse(t *testing.T, name, layout string, parse func(RO) (time.Time, error), s string) {
+	t.Helper()
+	for _, loc := range testLocals {
+		withLocal(loc, func() {
+			want, wantErr := time.Parse(layout, s)
+			got, err := parse(S(s))
+			if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+				t.Errorf("%s(%q) with Local %v: error = %v; want %v", name, s, loc, err, wantErr)
+				return
+			}
+			if !sameTime(got, want) {
+				t.Errorf("%s(%q) with Local %v = %v (%v); want %v (%v)", name, s, loc, got, got.Location(), want, want.Location())
+			}
+		})
+	}
+}
+
+var rfc3339Tests = []string{
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05+07:00",
+	"2006-01-02T15:04:05-07:00",
+	"2006-01-02T15:04:05+01:00",
+	"2006-01-02T15:04:05+05:30",
+	"2006-01-02T15:04:05+00:00",
+	"2006-01-02T15:04:05-00:00",
+	"2006-01-02T15:04:05.999999999Z",
+	"2006-01-02T15:04:05.1Z",
+	"2006-01-02T15:04:05.1234567891Z",
+	"2006-01-02T15:04:05,123Z",
+	"2006-01-02T15:04:05.Z",
+	"2000-02-29T00:00:00Z",
+	"2001-02-29T00:00:00Z",
+	"2006-04-31T00:00:00Z",
+	"0000-01-01T00:00:00Z",
+	"9999-12-31T23:59:59.999999999-23:59",
+	"2006-01-02T24:00:00Z",
+	"2006-01-02T15:60:00Z",
+	"2006-01-02T15:04:60Z",
+	"2006-01-02T15:04:05+24:00",
+	"2006-01-02t15:04:05z",
+	"2006-01-02 15:04:05Z",
+	"2006-1-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05Zjunk",
+	"2006-01-02T15:04:05+0700",
+	"",
+}
+
+func TestParseRFC3339(t *testing.T) {
+	for _, s := range rfc3339Tests {
+		checkParse(t, "ParseRFC3339", time.RFC3339, ParseRFC3339, s)
+
+		// RFC3339Nano accepts the same timestamps; only the layout in
+		// error messages differs.
+		want, wantErr := time.Parse(time.RFC3339Nano, s)
+		got, err := ParseRFC3339(S(s))
+		if (err == nil) != (wantErr == nil) || !sameTime(got, want) {
+			t.Errorf("ParseRFC3339(%q) = %v, %v; time.Parse(RFC3339Nano) = %v, %v", s, got, err, want, wantErr)
+		}
+	}
+}
+
+var rfc1123Tests = []string{
+	"Mon, 02 Jan 2006 15:04:05 UTC",
+	"Tue, 29 Feb 2000 00:00:00 UTC",
+	"Thu, 29 Feb 2001 00:00:00 UTC",
+	"Sun, 31 Dec 9999 23:59:59 UTC",
+	"Mon, 02 Jan 2006 15:04:05 GMT",
+	"Mon, 02 Jan 2006 15:04:05 CET",
+	"Mon, 02 Jan 2006 15:04:05 PST",
+	"Mon, 02 Jan 2006 15:04:05 GMT+3",
+	"Mon, 02 Jan 2006 15:04:05.123 UTC",
+	"mon, 02 jan 2006 15:04:05 UTC",
+	"Xyz, 02 Jan 2006 15:04:05 UTC",
+	"Mon, 2 Jan 2006 15:04:05 UTC",
+	"Mon, 02 Foo 2006 15:04:05 UTC",
+	"Mon, 02 Jan 2006 25:04:05 UTC",
+	"Mon 02 Jan 2006 15:04:05 UTC",
+	"",
+}
+
+func TestParseRFC1123(t *testing.T) {
+	for _, s := range rfc1123Tests {
+		checkParse(t, "ParseRFC1123", time.RFC1123, ParseRFC1123, s)
+	}
+}
+
+var stampTests = []string{
+	"Jan  2 15:04:05",
+	"Jan 02 15:04:05",
+	"Jan 12 15:04:05",
+	"Feb 29 00:00:00",
+	"Feb 30 00:00:00",
+	"Dec 31 23:59:59",
+	"Jan 2 15:04:05",
+	"Jan  2 15:04:05.123",
+	"jan  2 15:04:05",
+	"Jan  0 15:04:05",
+	"Jan 32 15:04:05",
+	"Jan  2 15:4:05",
+	"Jan  2 24:00:00",
+	"Jan  2 15:04:05 host",
+	"",
+}
+
+func TestParseStamp(t *testing.T) {
+	for _, s := range stampTests {
+		checkParse(t, "ParseStamp", time.Stamp, ParseStamp, s)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct{ layout, s string }{
+		{time.Kitchen, "3:04PM"},
+		{time.RFC850, "Monday, 02-Jan-06 15:04:05 MST"},
+		{time.RFC1123Z, "Mon, 02 Jan 2006 15:04:05 -0700"},
+		{"2006-01-02 15:04:05", "2006-01-02 15:04:05"},
+		{"2006-01-02", "2006-13-02"},
+	}
+	for _, tt := range tests {
+		checkParse(t, "ParseTime", tt.layout, func(m RO) (time.Time, error) { return ParseTime(tt.layout, m) }, tt.s)
+	}
+}
+
+func TestParseTimeOwnsInput(t *testing.T) {
+	// The zone abbreviation names a fixed zone.
+	b := []byte("Mon, 02 Jan 2006 15:04:05 XYZ")
+	tm, err := ParseTime(time.RFC1123, B(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(b[26:], "ABC")
+	if name, _ := tm.Zone(); name != "XYZ" {
+		t.Errorf("zone name changed after input was modified: %q", name)
+	}
+
+	b = []byte("Mon, 02 Jan 2006 15:04:05 XYZ junk")
+	_, err = ParseTime(time.RFC1123, B(b))
+	if err == nil {
+		t.Fatal("no error")
+	}
+	before := err.Error()
+	pe := err.(*time.ParseError)
+	elem := pe.ValueElem
+	for i := range b {
+		b[i] = 'z'
+	}
+	if after := err.Error(); after != before {
+		t.Errorf("error changed after input was modified: %q -> %q", before, after)
+	}
+	if pe.ValueElem != elem {
+		t.Errorf("ValueElem changed after input was modified: %q -> %q", elem, pe.ValueElem)
+	}
+}
+
+func TestParseUnix(t *testing.T) {
+	for _, s := range []string{"0", "1700000000", "-1", "1700000000123", "-1700000000123", "9223372036854775807", "-5"} {
+		sec, _ := strconv.ParseInt(s, 10, 64)
+		if got, err := ParseUnix(S(s)); err != nil || !sameTime(got, time.Unix(sec, 0)) {
+			t.Errorf("ParseUnix(%q) = %v, %v; want %v", s, got, err, time.Unix(sec, 0))
+		}
+		if got, err := ParseUnixMilli(S(s)); err != nil || !sameTime(got, time.UnixMilli(sec)) {
+			t.Errorf("ParseUnixMilli(%q) = %v, %v; want %v", s, got, err, time.UnixMilli(sec))
+		}
+	}
+	for _, s := range []string{"", "1.5", "x", "99999999999999999999"} {
+		if _, err := ParseUnix(S(s)); err == nil {
+			t.Errorf("ParseUnix(%q) succeeded", s)
+		}
+		if _, err := ParseUnixMilli(S(s)); err == nil {
+			t.Errorf("ParseUnixMilli(%q) succeeded", s)
+		}
+	}
+}
+
+func TestParseTimeAllocs(t *testing.T) {
+	if memDebug {
+		t.Skip("B allocates in memdebug builds")
+	}
+	inputs := []struct {
+		parse func(RO) (time.Time, error)
+		b     []byte
+	}{
+		{ParseRFC3339, []byte("2006-01-02T15:04:05Z")},
+		{ParseRFC3339, []byte("2006-01-02T15:04:05.123456+05:30")},
+		{ParseRFC1123, []byte("Mon, 02 Jan 2006 15:04:05 UTC")},
+		{ParseStamp, []byte("Jan  2 15:04:05")},
+		{ParseUnix, []byte("1700000000")},
+		{ParseUnixMilli, []byte("1700000000123")},
+	}
+	for _, in := range inputs {
+		in.parse(B(in.b)) // warm up the zone cache
+	}
+	n := testing.AllocsPerRun(100, func() {
+		for _, in := range inputs {
+			if _, err := in.parse(B(in.b)); err != nil {
+				panic(err)
+			}
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v; want 0", n)
+	}
+}
+
+func fuzzParse(f *testing.F, name, layout string, parse func(RO) (time.Time, error), seeds []string) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		checkParse(t, name, layout, parse, s)
+	})
+}
+
+func FuzzParseRFC3339(f *testing.F) {
+	fuzzParse(f, "ParseRFC3339", time.RFC3339, ParseRFC3339, rfc3339Tests)
+}
+
+func FuzzParseRFC1123(f *testing.F) {
+	fuzzParse(f, "ParseRFC1123", time.RFC1123, ParseRFC1123, rfc1123Tests)
+}
+
+func FuzzParseStamp(f *testing.F) {
+	fuzzParse(f, "ParseStamp", time.Stamp, ParseStamp, stampTests)
+}
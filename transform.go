@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The functions in this file transform an RO, appending the result to
+// a caller-supplied []byte and returning the possibly-reallocated
+// slice, like Append. They match the equivalent functions in package
+// strings.
+
+// isASCII reports whether m contains only ASCII bytes.
+func isASCII(m RO) bool {
+	for i := 0; i < len(m.m); i++ {
+		if m.m[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// appendRune appends the UTF-8 encoding of r to dst.
+func appendRune(dst []byte, r rune) []byte {
+	if uint32(r) < utf8.RuneSelf {
+		return append(dst, byte(r))
+	}
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(dst, buf[:n]...)
+}
+
+// appendASCIIMapped appends m, which must be ASCII, to dst, mapping
+// each byte through table.
+func appendASCIIMapped(dst []byte, m RO, table *[256]byte) []byte {
+	for i := 0; i < len(m.m); i++ {
+		dst = append(dst, table[m.m[i]])
+	}
+	return dst
+}
+
+// AppendMap appends to dst a copy of m with all its characters
+// modified according to the mapping function, like strings.Map. If
+// mapping returns a negative value, the character is dropped.
+func AppendMap(dst []byte, mapping func(rune) rune, m RO) []byte {
+	s := m.str()
+	for i, r := range s {
+		c := mapping(r)
+		if c == r && c != utf8.RuneError {
+			if r < utf8.RuneSelf {
+				dst = append(dst, s[i])
+			} else {
+				_, size := utf8.DecodeRuneInString(s[i:])
+				dst = append(dst, s[i:i+size]...)
+			}
+			continue
+		}
+		if c >= 0 {
+			dst = appendRune(dst, c)
+		}
+	}
+	return dst
+}
+
+// AppendToLower appends m with all Unicode letters mapped to their
+// lower case to dst, like strings.ToLower.
+func AppendToLower(dst []byte, m RO) []byte {
+	if isASCII(m) {
+		return appendASCIIMapped(dst, m, &asciiLower)
+	}
+	return AppendMap(dst, unicode.ToLower, m)
+}
+
+// AppendToUpper appends m with all Unicode letters mapped to their
+// upper case to dst, like strings.ToUpper.
+func AppendToUpper(dst []byte, m RO) []byte {
+	if isASCII(m) {
+		return appendASCIIMapped(dst, m, &asciiUpper)
+	}
+	return AppendMap(dst, unicode.ToUpper, m)
+}
+
+// AppendToTitle appends m with all Unicode letters mapped to their
+// title case to dst, like strings.ToTitle.
+func AppendToTitle(dst []byte, m RO) []byte {
+	if isASCII(m) {
+		return appendASCIIMapped(dst, m, &asciiUpper)
+	}
+	return AppendMap(dst, unicode.ToTitle, m)
+}
+
+// AppendToValidUTF8 appends m to dst with each run of invalid UTF-8
+// byte sequences replaced by replacement, which may be empty, like
+// strings.ToValidUTF8.
+func AppendToValidUTF8(dst []byte, m, replacement RO) []byte {
+	s := m.str()
+	invalid := false // previous byte was from an invalid UTF-8 sequence
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			dst = append(dst, c)
+			i++
+			invalid = false
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		if size == 1 {
+			if !invalid {
+				invalid = true
+				dst = append(dst, replacement.m...)
+			}
+			i++
+			continue
+		}
+		invalid = false
+		dst = append(dst, s[i:i+size]...)
+		i += size
+	}
+	return dst
+}
+
+// AppendReplace appends to dst a copy of m with the first n
+// non-overlapping instances of old replaced by new, like
+// strings.Replace. If old is empty, it matches at the beginning of m
+// and after each UTF-8 sequence. If n < 0, there is no limit on the
+// number of replacements.
+func AppendReplace(dst []byte, m, old, new RO, n int) []byte {
+	// Copied from the Go standard library (BSD license).
+	if old.Equal(new) || n == 0 {
+		return append(dst, m.m...)
+	}
+
+	// Compute number of replacements.
+	if c := strings.Count(m.str(), old.str()); c == 0 {
+		return append(dst, m.m...)
+	} else if n < 0 || c < n {
+		n = c
+	}
+
+	start := 0
+	for i := 0; i < n; i++ {
+		j := start
+		if old.Len() == 0 {
+			if i > 0 {
+				_, wid := DecodeRune(m.SliceFrom(start))
+				j += wid
+			}
+		} else {
+			j += Index(m.SliceFrom(start), old)
+		}
+		dst = append(dst, m.m[start:j]...)
+		dst = append(dst, new.m...)
+		start = j + old.Len()
+	}
+	return append(dst, m.m[start:]...)
+}
+
+// AppendRepeat appends count copies of m to dst, like strings.Repeat.
+//
+// It panics if count is negative or if the result would overflow.
+func AppendRepeat(dst []byte, m RO, count int) []byte {
+	if count < 0 {
+		panic("mem: negative Repeat count")
+	}
+	if count == 0 || m.Len() == 0 {
+		return dst
+	}
+	if m.Len() > maxInt/count {
+		panic("mem: Repeat output length overflow")
+	}
+	n := m.Len() * count
+	if cap(dst)-len(dst) < n {
+		grown := make([]byte, len(dst), len(dst)+n)
+		copy(grown, dst)
+		dst = grown
+	}
+	// Append m once, then double what's been written.
+	start := len(dst)
+	dst = append(dst, m.m...)
+	for len(dst)-start < n {
+		chunk := len(dst) - start
+		if chunk > n-chunk {
+			chunk = n - chunk
+		}
+		dst = append(dst, dst[start:start+chunk]...)
+	}
+	return dst
+}
+
+const maxInt = int(^uint(0) >> 1)
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Go4 AUTHORS
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mem
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+var transformInputs = []string{
+	"",
+	"abc",
+	"AbC-123 xyz",
+	"Straße ǅ ǆ Ǆ",
+	"ǲ ﬁ K ſ",
+	"bad\xffutf8\xe2\x82\xc0\xafend",
+	"\xff\xfe",
+	"☺☻☹",
+}
+
+func TestAppendCase(t *testing.T) {
+	funcs := []struct {
+		name string
+		mem  func([]byte, RO) []byte
+		std  func(string) string
+	}{
+		{"ToLower", AppendToLower, strings.ToLower},
+		{"ToUpper", AppendToUpper, strings.ToUpper},
+		{"ToTitle", AppendToTitle, strings.ToTitle},
+	}
+	for _, f := range funcs {
+		for _, in := range transformInputs {
+			got := f.mem([]byte("prefix:"), S(in))
+			if want := "prefix:" + f.std(in); string(got) != want {
+				t.Errorf("Append%s(%q) = %q; want %q", f.name, in, got, want)
+			}
+		}
+	}
+}
+
+func TestAppendMap(t *testing.T) {
+	mappings := map[string]func(rune) rune{
+		"identity": func(r rune) rune { return r },
+		"drop a": func(r rune) rune {
+			if r == 'a' {
+				return -1
+			}
+			return r
+		},
+		"rot1":    func(r rune) rune { return r + 1 },
+		"to FFFD": func(rune) rune { return unicode.ReplacementChar },
+	}
+	for name, mapping := range mappings {
+		for _, in := range transformInputs {
+			got := AppendMap(nil, mapping, S(in))
+			if want := strings.Map(mapping, in); string(got) != want {
+				t.Errorf("AppendMap(%s, %q) = %q; want %q", name, in, got, want)
+			}
+		}
+	}
+}
+
+func TestAppendToValidUTF8(t *testing.T) {
+	for _, in := range transformInputs {
+		for _, repl := range []string{"", "?", "�"} {
+			got := AppendToValidUTF8(nil, S(in), S(repl))
+			if want := strings.ToValidUTF8(in, repl); string(got) != want {
+				t.Errorf("AppendToValidUTF8(%q, %q) = %q; want %q", in, repl, got, want)
+			}
+		}
+	}
+}
+
+func TestAppendReplace(t *testing.T) {
+	tests := []struct {
+		in, old, new string
+	}{
+		{"hello", "l", "L"},
+		{"hello", "x", "L"},
+		{"hello", "", "<>"},
+		{"", "", "<>"},
+		{"☺☻☹", "", "-"},
+		{"banana", "a", "a"},
+		{"banana", "ana", "*"},
+		{"banana", "a", ""},
+	}
+	for _, tt := range tests {
+		for _, n := range []int{-1, 0, 1, 2, 100} {
+			got := AppendReplace(nil, S(tt.in), S(tt.old), S(tt.new), n)
+			if want := strings.Replace(tt.in, tt.old, tt.new, n); string(got) != want {
+				t.Errorf("AppendReplace(%q, %q, %q, %d) = %q; want %q", tt.in, tt.old, tt.new, n, got, want)
+			}
+		}
+	}
+}
+
+func TestAppendRepeat(t *testing.T) {
+	for _, in := range []string{"", "x", "abc"} {
+		for _, count := range []int{0, 1, 2, 7, 64} {
+			got := AppendRepeat([]byte("p"), S(in), count)
+			if want := "p" + strings.Repeat(in, count); string(got) != want {
+				t.Errorf("AppendRepeat(%q, %d) = %q; want %q", in, count, got, want)
+			}
+		}
+	}
+	for _, count := range []int{-1, maxInt/2 + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("AppendRepeat(%d) didn't panic", count)
+				}
+			}()
+			AppendRepeat(nil, S("ab"), count)
+		}()
+	}
+}
+
+func TestAppendTransformAllocs(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	m := B([]byte("Some Header-Name"))
+	u := B([]byte("Straße"))
+	n := int(testing.AllocsPerRun(1000, func() {
+		buf = AppendToLower(buf[:0], m)
+		buf = AppendToUpper(buf, u)
+		buf = AppendReplace(buf, m, S("-"), S("_"), -1)
+		buf = AppendRepeat(buf, S("ab"), 3)
+	}))
+	if n != 0 {
+		t.Fatalf("allocs = %d; want 0", n)
+	}
+}